@@ -0,0 +1,68 @@
+package epay
+
+import (
+	"context"
+	"sync"
+)
+
+// 批量查询默认并发数
+const defaultBatchConcurrency = 8
+
+// OrderLookupResult 批量查询中单个订单的结果
+type OrderLookupResult struct {
+	OutTradeNo string       // 商户订单号
+	Order      *OrderDetail // 订单详情（查询失败时为 nil）
+	Err        error        // 查询错误
+}
+
+// QueryOrdersByOutTradeNo 按商户订单号并发批量查询订单
+// concurrency 为最大并发数（<=0 时默认 8），请求受客户端速率限制约束，
+// 网络错误按客户端的重试策略重试。返回结果与 outTradeNos 顺序一一对应。
+// context 取消后，尚未查询的订单返回 context 错误。
+func (c *Client) QueryOrdersByOutTradeNo(ctx context.Context, outTradeNos []string, concurrency int) []OrderLookupResult {
+	if concurrency <= 0 {
+		concurrency = defaultBatchConcurrency
+	}
+	if concurrency > len(outTradeNos) {
+		concurrency = len(outTradeNos)
+	}
+
+	results := make([]OrderLookupResult, len(outTradeNos))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				results[idx] = c.lookupOrder(ctx, outTradeNos[idx])
+			}
+		}()
+	}
+
+	for idx := range outTradeNos {
+		jobs <- idx
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// lookupOrder 查询单个商户订单
+func (c *Client) lookupOrder(ctx context.Context, outTradeNo string) OrderLookupResult {
+	result := OrderLookupResult{OutTradeNo: outTradeNo}
+
+	if err := ctx.Err(); err != nil {
+		result.Err = err
+		return result
+	}
+
+	result.Err = c.withRetry(ctx, func() error {
+		order, err := c.QueryOrderContext(ctx, &OrderQueryRequest{OutTradeNo: outTradeNo})
+		result.Order = order
+		return err
+	})
+	return result
+}
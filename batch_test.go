@@ -0,0 +1,69 @@
+package epay
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+)
+
+func TestClient_QueryOrdersByOutTradeNo(t *testing.T) {
+	var inflight, maxInflight int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inflight, 1)
+		defer atomic.AddInt32(&inflight, -1)
+		for {
+			max := atomic.LoadInt32(&maxInflight)
+			if n <= max || atomic.CompareAndSwapInt32(&maxInflight, max, n) {
+				break
+			}
+		}
+
+		outTradeNo := r.URL.Query().Get("out_trade_no")
+		if outTradeNo == "MISSING" {
+			w.Write([]byte(`{"code":-1,"msg":"订单号不存在"}`))
+			return
+		}
+		fmt.Fprintf(w, `{"code":1,"out_trade_no":"%s","money":"1.00","status":1}`, outTradeNo)
+	})
+
+	ids := []string{"A", "B", "MISSING", "C", "D", "E"}
+	results := client.QueryOrdersByOutTradeNo(context.Background(), ids, 2)
+
+	if len(results) != len(ids) {
+		t.Fatalf("len(results) = %d, want %d", len(results), len(ids))
+	}
+	for i, result := range results {
+		if result.OutTradeNo != ids[i] {
+			t.Errorf("results[%d].OutTradeNo = %s, want %s", i, result.OutTradeNo, ids[i])
+		}
+		if ids[i] == "MISSING" {
+			if result.Err == nil {
+				t.Error("expected error for MISSING order")
+			}
+			continue
+		}
+		if result.Err != nil || !IsOrderPaid(result.Order) {
+			t.Errorf("results[%d] = %+v, want paid order", i, result)
+		}
+	}
+	if maxInflight > 2 {
+		t.Errorf("max concurrent requests = %d, want <= 2", maxInflight)
+	}
+}
+
+func TestClient_QueryOrdersByOutTradeNo_Canceled(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"code":1,"status":1}`))
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for _, result := range client.QueryOrdersByOutTradeNo(ctx, []string{"A", "B"}, 4) {
+		if result.Err != context.Canceled {
+			t.Errorf("Err = %v, want context.Canceled", result.Err)
+		}
+	}
+}
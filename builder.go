@@ -58,6 +58,32 @@ func (b *ClientBuilder) WithHTTPTimeout(timeout time.Duration) *ClientBuilder {
 	return b
 }
 
+// WithTimeZone 设置网关时区（如: Asia/Shanghai）
+func (b *ClientBuilder) WithTimeZone(name string) *ClientBuilder {
+	b.config.TimeZone = name
+	return b
+}
+
+// WithRetry 设置查询类请求的重试策略
+// maxRetries 为最大重试次数，interval 为首次重试间隔（之后按指数退避）
+func (b *ClientBuilder) WithRetry(maxRetries int, interval time.Duration) *ClientBuilder {
+	b.config.MaxRetries = maxRetries
+	b.config.RetryInterval = int(interval.Milliseconds())
+	return b
+}
+
+// WithRateLimit 设置每秒最大请求数（<=0 表示不限制）
+func (b *ClientBuilder) WithRateLimit(rps float64) *ClientBuilder {
+	b.config.RateLimit = rps
+	return b
+}
+
+// WithCancelStore 设置已取消订单存储
+func (b *ClientBuilder) WithCancelStore(store CancelStore) *ClientBuilder {
+	b.config.CancelStore = store
+	return b
+}
+
 // Build 构建客户端
 func (b *ClientBuilder) Build() (*Client, error) {
 	return NewClient(b.config)
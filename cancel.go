@@ -0,0 +1,150 @@
+package epay
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// API 接口路径
+const (
+	APIPathClose = "/api.php" // 关闭订单接口
+)
+
+// CancelStore 已取消订单存储
+// CloseOrder 会将订单标记为已取消，VerifyNotify 据此识别取消后才到达的支付成功通知
+type CancelStore interface {
+	// MarkCancelled 标记商户订单已取消
+	MarkCancelled(outTradeNo string) error
+	// IsCancelled 检查商户订单是否已取消
+	IsCancelled(outTradeNo string) (bool, error)
+}
+
+// MemoryCancelStore 基于内存的已取消订单存储（并发安全，重启后丢失）
+type MemoryCancelStore struct {
+	mu        sync.RWMutex
+	cancelled map[string]struct{}
+}
+
+// NewMemoryCancelStore 创建内存已取消订单存储
+func NewMemoryCancelStore() *MemoryCancelStore {
+	return &MemoryCancelStore{
+		cancelled: make(map[string]struct{}),
+	}
+}
+
+// MarkCancelled 标记商户订单已取消
+func (s *MemoryCancelStore) MarkCancelled(outTradeNo string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cancelled[outTradeNo] = struct{}{}
+	return nil
+}
+
+// IsCancelled 检查商户订单是否已取消
+func (s *MemoryCancelStore) IsCancelled(outTradeNo string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.cancelled[outTradeNo]
+	return ok, nil
+}
+
+// CloseOrder 关闭未支付订单
+// 先向网关查询订单：非未支付状态时按状态返回 ErrOrderAlreadyPaid、ErrOrderRefunded 或 ErrOrderFrozen，不做任何标记；
+// 未支付（或网关明确返回订单不存在）时在本地标记为已取消，再请求网关关闭订单。
+// 查询失败（包括订单不存在以外的业务错误）时无法确认支付状态，直接返回错误。
+// 网关不支持关闭或关闭失败时，订单仍保持本地取消状态，
+// 之后到达的支付成功通知会被标记为 RefundRequired。
+func (c *Client) CloseOrder(req *CloseOrderRequest) (*CloseOrderResult, error) {
+	// 验证参数
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	// 查询订单，已支付的订单不能关闭
+	detail, err := c.QueryOrder(&OrderQueryRequest{TradeNo: req.TradeNo, OutTradeNo: req.OutTradeNo})
+	switch {
+	case err == nil:
+		if err := closableStatus(detail.Status); err != nil {
+			return nil, err
+		}
+	case isOrderNotFound(err):
+		// 网关上不存在该订单（用户尚未进入收银台），按未支付处理
+	default:
+		// 无法确认支付状态，不标记取消
+		return nil, err
+	}
+
+	// 本地标记取消（先于关闭请求，避免通知与关闭竞争）
+	if err := c.cancelStore.MarkCancelled(req.OutTradeNo); err != nil {
+		return nil, WrapError(ErrCodeStoreError, "mark order cancelled failed", err)
+	}
+
+	result := &CloseOrderResult{
+		OutTradeNo: req.OutTradeNo,
+	}
+
+	// 构建请求参数
+	params := c.buildBaseParams()
+	params["act"] = "close"
+	params["out_trade_no"] = req.OutTradeNo
+	if req.TradeNo != "" {
+		params["trade_no"] = req.TradeNo
+	}
+
+	// 发送请求
+	body, err := c.doGet(APIPathClose, params)
+	if err != nil {
+		result.GatewayError = err
+		return result, nil
+	}
+
+	// 解析响应
+	resp, err := parseJSONResponse[CloseOrderResponse](body)
+	if err != nil {
+		result.GatewayError = err
+		return result, nil
+	}
+
+	// 检查业务错误
+	if resp.Code != 1 {
+		result.GatewayError = NewError(ErrCodeAPIError, resp.Msg)
+		return result, nil
+	}
+
+	result.GatewayClosed = true
+	return result, nil
+}
+
+// closableStatus 检查网关订单状态是否允许关闭
+func closableStatus(status OrderStatus) error {
+	switch status {
+	case OrderStatusUnpaid:
+		return nil
+	case OrderStatusPaid:
+		return ErrOrderAlreadyPaid
+	case OrderStatusRefunded:
+		return ErrOrderRefunded
+	case OrderStatusFrozen:
+		return ErrOrderFrozen
+	default:
+		return NewError(ErrCodeInvalidResponse, fmt.Sprintf("unknown order status %d, cannot close", int(status)))
+	}
+}
+
+// isOrderNotFound 检查订单查询错误是否为网关明确返回的订单不存在
+// EPay 对不存在的订单返回业务错误，msg 为 "订单号不存在" 一类的提示
+func isOrderNotFound(err error) bool {
+	var epayErr *EPayError
+	if !errors.As(err, &epayErr) || epayErr.Code != ErrCodeAPIError {
+		return false
+	}
+	msg := strings.ToLower(epayErr.Message)
+	return strings.Contains(msg, "不存在") || strings.Contains(msg, "not exist") || strings.Contains(msg, "not found")
+}
+
+// IsOrderCancelled 检查商户订单是否已通过 CloseOrder 取消
+func (c *Client) IsOrderCancelled(outTradeNo string) (bool, error) {
+	return c.cancelStore.IsCancelled(outTradeNo)
+}
@@ -0,0 +1,142 @@
+package epay
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestClient_CloseOrder(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch act := r.URL.Query().Get("act"); act {
+		case "order":
+			w.Write([]byte(`{"code":1,"out_trade_no":"ORDER001","status":0}`))
+		case "close":
+			w.Write([]byte(`{"code":1,"msg":"succ"}`))
+		default:
+			t.Errorf("unexpected act %s", act)
+		}
+	})
+
+	result, err := client.CloseOrder(&CloseOrderRequest{OutTradeNo: "ORDER001"})
+	if err != nil {
+		t.Fatalf("CloseOrder() error = %v", err)
+	}
+	if !result.GatewayClosed || result.GatewayError != nil {
+		t.Errorf("CloseOrder() = %+v, want gateway closed", result)
+	}
+}
+
+func TestClient_CloseOrder_LocalFallback(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("act") == "order" {
+			// 用户尚未进入收银台，网关上不存在该订单
+			w.Write([]byte(`{"code":-1,"msg":"订单号不存在"}`))
+			return
+		}
+		w.Write([]byte(`{"code":-4,"msg":"No Act!"}`))
+	})
+
+	result, err := client.CloseOrder(&CloseOrderRequest{OutTradeNo: "ORDER001"})
+	if err != nil {
+		t.Fatalf("CloseOrder() error = %v", err)
+	}
+	if result.GatewayClosed || result.GatewayError == nil {
+		t.Errorf("CloseOrder() = %+v, want local fallback", result)
+	}
+
+	// 取消后到达的支付成功通知需要退款
+	params := map[string]string{
+		"pid":          "1001",
+		"trade_no":     "T001",
+		"out_trade_no": "ORDER001",
+		"money":        "10.00",
+		"trade_status": TradeStatusSuccess,
+	}
+	params["sign"] = client.Sign(params)
+
+	notifyData, err := client.VerifyNotify(params)
+	if err != nil {
+		t.Fatalf("VerifyNotify() error = %v", err)
+	}
+	if !notifyData.RefundRequired {
+		t.Error("RefundRequired = false, want true")
+	}
+}
+
+func TestClient_CloseOrder_AlreadyPaid(t *testing.T) {
+	closed := false
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("act") == "close" {
+			closed = true
+		}
+		w.Write([]byte(`{"code":1,"out_trade_no":"ORDER001","status":1}`))
+	})
+
+	if _, err := client.CloseOrder(&CloseOrderRequest{OutTradeNo: "ORDER001"}); err != ErrOrderAlreadyPaid {
+		t.Fatalf("CloseOrder() error = %v, want ErrOrderAlreadyPaid", err)
+	}
+	if closed {
+		t.Error("CloseOrder() should not close a paid order")
+	}
+	if cancelled, _ := client.IsOrderCancelled("ORDER001"); cancelled {
+		t.Error("paid order should not be marked cancelled")
+	}
+}
+
+func TestClient_CloseOrder_QueryFailed(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	if _, err := client.CloseOrder(&CloseOrderRequest{OutTradeNo: "ORDER001"}); err == nil {
+		t.Fatal("CloseOrder() error = nil, want error")
+	}
+	if cancelled, _ := client.IsOrderCancelled("ORDER001"); cancelled {
+		t.Error("order should not be marked cancelled when payment status is unknown")
+	}
+}
+
+func TestClient_CloseOrder_Status(t *testing.T) {
+	tests := []struct {
+		status string
+		want   error
+	}{
+		{"1", ErrOrderAlreadyPaid},
+		{"2", ErrOrderRefunded},
+		{"3", ErrOrderFrozen},
+	}
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Query().Get("act") == "close" {
+					t.Error("CloseOrder() should not close the order")
+				}
+				w.Write([]byte(`{"code":1,"out_trade_no":"ORDER001","status":` + tt.status + `}`))
+			})
+
+			if _, err := client.CloseOrder(&CloseOrderRequest{OutTradeNo: "ORDER001"}); err != tt.want {
+				t.Fatalf("CloseOrder() error = %v, want %v", err, tt.want)
+			}
+			if cancelled, _ := client.IsOrderCancelled("ORDER001"); cancelled {
+				t.Error("order should not be marked cancelled")
+			}
+		})
+	}
+}
+
+func TestClient_CloseOrder_QueryAPIError(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("act") == "close" {
+			t.Error("CloseOrder() should not close the order")
+		}
+		// 订单不存在以外的业务错误，无法确认支付状态
+		w.Write([]byte(`{"code":-3,"msg":"签名错误"}`))
+	})
+
+	if _, err := client.CloseOrder(&CloseOrderRequest{OutTradeNo: "ORDER001"}); err == nil {
+		t.Fatal("CloseOrder() error = nil, want error")
+	}
+	if cancelled, _ := client.IsOrderCancelled("ORDER001"); cancelled {
+		t.Error("order should not be marked cancelled when the query failed")
+	}
+}
@@ -1,6 +1,7 @@
 package epay
 
 import (
+	"context"
 	"encoding/json"
 	"io"
 	"log"
@@ -8,6 +9,7 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // Client EPay SDK 客户端
@@ -15,6 +17,11 @@ type Client struct {
 	config     *Config
 	httpClient *http.Client
 	signer     *Signer
+	location   *time.Location
+	limiter    *rateLimiter
+
+	cancelStore CancelStore
+	waiters     *paymentWaiters
 }
 
 // NewClient 创建 EPay 客户端
@@ -32,10 +39,20 @@ func NewClient(config *Config) (*Client, error) {
 	// 创建签名器
 	signer := NewSigner(config.Key)
 
+	// 已取消订单存储
+	cancelStore := config.CancelStore
+	if cancelStore == nil {
+		cancelStore = NewMemoryCancelStore()
+	}
+
 	return &Client{
-		config:     config,
-		httpClient: httpClient,
-		signer:     signer,
+		config:      config,
+		httpClient:  httpClient,
+		signer:      signer,
+		location:    config.GetLocation(),
+		limiter:     newRateLimiter(config.RateLimit),
+		cancelStore: cancelStore,
+		waiters:     newPaymentWaiters(),
 	}, nil
 }
 
@@ -53,6 +70,11 @@ func (c *Client) buildBaseParams() map[string]string {
 
 // doGet 执行 GET 请求
 func (c *Client) doGet(endpoint string, params map[string]string) ([]byte, error) {
+	return c.doGetContext(context.Background(), endpoint, params)
+}
+
+// doGetContext 执行带 context 的 GET 请求
+func (c *Client) doGetContext(ctx context.Context, endpoint string, params map[string]string) ([]byte, error) {
 	// 构建 URL
 	reqURL := c.config.GetAPIBaseURL() + endpoint
 
@@ -67,8 +89,17 @@ func (c *Client) doGet(endpoint string, params map[string]string) ([]byte, error
 		log.Printf("[EPay SDK] GET %s", fullURL)
 	}
 
+	// 速率限制
+	if err := c.limiter.Wait(ctx); err != nil {
+		return nil, WrapError(ErrCodeNetworkError, "wait for rate limiter failed", err)
+	}
+
 	// 发送请求
-	resp, err := c.httpClient.Get(fullURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
+	if err != nil {
+		return nil, WrapError(ErrCodeInvalidParam, "build HTTP request failed", err)
+	}
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return nil, WrapError(ErrCodeNetworkError, "HTTP request failed", err)
 	}
@@ -105,6 +136,11 @@ func (c *Client) doPost(endpoint string, params map[string]string) ([]byte, erro
 		log.Printf("[EPay SDK] POST %s, params: %v", reqURL, signedParams)
 	}
 
+	// 速率限制
+	if err := c.limiter.Wait(context.Background()); err != nil {
+		return nil, WrapError(ErrCodeNetworkError, "wait for rate limiter failed", err)
+	}
+
 	// 发送请求
 	resp, err := c.httpClient.Post(reqURL, "application/x-www-form-urlencoded", strings.NewReader(formData.Encode()))
 	if err != nil {
@@ -164,6 +200,15 @@ func (c *Client) VerifyNotify(params map[string]string) (*NotifyData, error) {
 		SignType:    params["sign_type"],
 	}
 
+	// 已取消订单收到支付成功通知，标记为需要退款
+	if notifyData.TradeStatus == TradeStatusSuccess && notifyData.OutTradeNo != "" {
+		cancelled, err := c.cancelStore.IsCancelled(notifyData.OutTradeNo)
+		if err != nil {
+			return nil, WrapError(ErrCodeStoreError, "check cancelled order failed", err)
+		}
+		notifyData.RefundRequired = cancelled
+	}
+
 	return notifyData, nil
 }
 
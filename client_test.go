@@ -1,11 +1,34 @@
 package epay
 
 import (
+	"bytes"
+	"errors"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
 )
 
+// newTestClient 创建指向测试服务器的客户端
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(&Config{
+		PID:        1001,
+		Key:        "testkey123",
+		APIBaseURL: server.URL,
+	})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	return client
+}
+
 func TestNewClient(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -328,7 +351,10 @@ func TestParseNotifyParams(t *testing.T) {
 	// 创建测试请求
 	req := httptest.NewRequest(http.MethodGet, "/notify?pid=1001&out_trade_no=ORDER001&money=10.00", nil)
 
-	params := ParseNotifyParams(req)
+	params, err := ParseNotifyParams(req)
+	if err != nil {
+		t.Fatalf("ParseNotifyParams() error = %v", err)
+	}
 
 	if params["pid"] != "1001" {
 		t.Errorf("ParseNotifyParams() pid = %s, want 1001", params["pid"])
@@ -341,6 +367,123 @@ func TestParseNotifyParams(t *testing.T) {
 	}
 }
 
+func TestParseNotifyParams_Body(t *testing.T) {
+	var multipartBody bytes.Buffer
+	mw := multipart.NewWriter(&multipartBody)
+	mw.WriteField("pid", "1001")
+	mw.WriteField("money", "10.00")
+	mw.Close()
+
+	tests := []struct {
+		name        string
+		target      string
+		contentType string
+		body        string
+		want        map[string]string
+		wantErr     bool
+	}{
+		{
+			name:        "form",
+			target:      "/notify",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "pid=1001&money=10.00",
+			want:        map[string]string{"pid": "1001", "money": "10.00"},
+		},
+		{
+			name:        "json",
+			target:      "/notify",
+			contentType: "application/json; charset=utf-8",
+			body:        `{"pid":1001,"money":"10.00","param":null,"ok":true}`,
+			want:        map[string]string{"pid": "1001", "money": "10.00", "param": "", "ok": "true"},
+		},
+		{
+			name:        "multipart",
+			target:      "/notify",
+			contentType: mw.FormDataContentType(),
+			body:        multipartBody.String(),
+			want:        map[string]string{"pid": "1001", "money": "10.00"},
+		},
+		{
+			name:        "identical duplicate in query and body",
+			target:      "/notify?pid=1001",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "pid=1001",
+			want:        map[string]string{"pid": "1001"},
+		},
+		{
+			name:        "conflicting duplicate in query and body",
+			target:      "/notify?money=0.01",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "money=10.00",
+			wantErr:     true,
+		},
+		{
+			name:        "conflicting duplicate in form",
+			target:      "/notify",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "money=10.00&money=0.01",
+			wantErr:     true,
+		},
+		{
+			name:        "conflicting duplicate in json",
+			target:      "/notify",
+			contentType: "application/json",
+			body:        `{"money":"10.00","money":"0.01"}`,
+			wantErr:     true,
+		},
+		{
+			name:        "nested json",
+			target:      "/notify",
+			contentType: "application/json",
+			body:        `{"money":{"value":"10.00"}}`,
+			wantErr:     true,
+		},
+		{
+			name:        "trailing json",
+			target:      "/notify",
+			contentType: "application/json",
+			body:        `{"money":"10.00"}{}`,
+			wantErr:     true,
+		},
+		{
+			name:        "unsupported content type",
+			target:      "/notify",
+			contentType: "text/xml",
+			body:        "<xml/>",
+			wantErr:     true,
+		},
+		{
+			name:        "body too large",
+			target:      "/notify",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "name=" + strings.Repeat("a", DefaultMaxNotifyBodySize),
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.target, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+
+			params, err := ParseNotifyParams(req)
+			if tt.wantErr {
+				var epayErr *EPayError
+				if !errors.As(err, &epayErr) || epayErr.Code != ErrCodeInvalidParam {
+					t.Fatalf("ParseNotifyParams() error = %v, want ErrCodeInvalidParam", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseNotifyParams() error = %v", err)
+			}
+			if !reflect.DeepEqual(params, tt.want) {
+				t.Errorf("ParseNotifyParams() = %v, want %v", params, tt.want)
+			}
+		})
+	}
+}
+
 func TestBuildFormPaymentURL(t *testing.T) {
 	client, _ := NewClient(&Config{
 		PID:        1001,
@@ -11,6 +11,12 @@ const (
 
 	// DefaultSignType 默认签名类型
 	DefaultSignType = "MD5"
+
+	// DefaultTimeZone 默认网关时区
+	DefaultTimeZone = "Asia/Shanghai"
+
+	// DefaultRetryInterval 默认首次重试间隔（毫秒）
+	DefaultRetryInterval = 200
 )
 
 // Config EPay SDK 配置
@@ -20,6 +26,14 @@ type Config struct {
 	APIBaseURL string // API 基础URL（如: https://pay.example.com）
 	Timeout    int    // 请求超时时间（秒，默认: 30）
 	Debug      bool   // 是否开启调试模式
+	TimeZone   string // 网关时区，用于解析订单时间（默认: Asia/Shanghai）
+
+	MaxRetries    int // 查询类请求遇到网络错误时的最大重试次数（默认: 0，不重试）
+	RetryInterval int // 首次重试间隔（毫秒，默认: 200），之后按指数退避
+
+	RateLimit float64 // 每秒最大请求数（默认: 0，不限制）
+
+	CancelStore CancelStore // 已取消订单存储（默认: 内存存储）
 }
 
 // Validate 验证配置是否有效
@@ -33,6 +47,11 @@ func (c *Config) Validate() error {
 	if c.APIBaseURL == "" {
 		return ErrInvalidAPIURL
 	}
+	if c.TimeZone != "" && c.TimeZone != DefaultTimeZone {
+		if _, err := time.LoadLocation(c.TimeZone); err != nil {
+			return WrapError(ErrCodeInvalidConfig, "invalid TimeZone", err)
+		}
+	}
 	return nil
 }
 
@@ -44,7 +63,32 @@ func (c *Config) GetTimeout() time.Duration {
 	return time.Duration(c.Timeout) * time.Second
 }
 
+// GetRetryInterval 获取首次重试间隔
+func (c *Config) GetRetryInterval() time.Duration {
+	if c.RetryInterval <= 0 {
+		return time.Duration(DefaultRetryInterval) * time.Millisecond
+	}
+	return time.Duration(c.RetryInterval) * time.Millisecond
+}
+
 // GetAPIBaseURL 获取 API 基础 URL（去除尾部斜杠）
 func (c *Config) GetAPIBaseURL() string {
 	return strings.TrimRight(c.APIBaseURL, "/")
 }
+
+// GetLocation 获取网关时区
+// 系统缺少时区数据时，默认时区回退为 UTC+8
+func (c *Config) GetLocation() *time.Location {
+	name := c.TimeZone
+	if name == "" {
+		name = DefaultTimeZone
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		if name == DefaultTimeZone {
+			return time.FixedZone("CST", 8*60*60)
+		}
+		return time.UTC
+	}
+	return loc
+}
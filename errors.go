@@ -11,6 +11,7 @@ const (
 	ErrCodeNetworkError    = 1005 // 网络错误
 	ErrCodeInvalidResponse = 1006 // 响应格式错误
 	ErrCodeInvalidParam    = 1007 // 参数错误
+	ErrCodeStoreError      = 1008 // 存储错误
 )
 
 // EPayError SDK 错误
@@ -61,6 +62,10 @@ var (
 	ErrMissingName       = NewError(ErrCodeInvalidParam, "name is required")
 	ErrInvalidMoney      = NewError(ErrCodeInvalidParam, "money must be greater than 0")
 	ErrMissingTradeNo    = NewError(ErrCodeInvalidParam, "trade_no or out_trade_no is required")
+	ErrMissingRefundNo   = NewError(ErrCodeInvalidParam, "refund_no or out_refund_no is required")
+	ErrOrderAlreadyPaid  = NewError(ErrCodeInvalidParam, "order already paid, cannot close")
+	ErrOrderRefunded     = NewError(ErrCodeInvalidParam, "order already refunded, cannot close")
+	ErrOrderFrozen       = NewError(ErrCodeInvalidParam, "order frozen, cannot close")
 
 	ErrSignVerifyFailed = NewError(ErrCodeVerifyFailed, "signature verification failed")
 )
@@ -284,7 +284,12 @@ func queryOrderHandler(w http.ResponseWriter, r *http.Request) {
 // notifyHandler 支付回调处理
 func notifyHandler(w http.ResponseWriter, r *http.Request) {
 	// 解析回调参数
-	params := epay.ParseNotifyParams(r)
+	params, err := epay.ParseNotifyParams(r)
+	if err != nil {
+		log.Printf("解析回调参数失败: %v", err)
+		w.Write([]byte("fail"))
+		return
+	}
 
 	log.Printf("收到支付回调: %+v", params)
 
@@ -328,12 +333,15 @@ func notifyHandler(w http.ResponseWriter, r *http.Request) {
 
 // returnHandler 同步回调页面
 func returnHandler(w http.ResponseWriter, r *http.Request) {
-	params := epay.ParseNotifyParams(r)
+	params, err := epay.ParseNotifyParams(r)
+	if err != nil {
+		http.Error(w, "参数错误", http.StatusBadRequest)
+		return
+	}
 	outTradeNo := params["out_trade_no"]
 
 	// 验证签名
-	_, err := client.VerifyNotify(params)
-	if err != nil {
+	if _, err := client.VerifyNotify(params); err != nil {
 		log.Printf("Return 签名验证失败: %v", err)
 		http.Error(w, "签名验证失败", http.StatusBadRequest)
 		return
@@ -209,7 +209,12 @@ func urlPaymentHandler(w http.ResponseWriter, r *http.Request) {
 // notifyHandler 支付回调处理
 func notifyHandler(w http.ResponseWriter, r *http.Request) {
 	// 解析回调参数
-	params := epay.ParseNotifyParams(r)
+	params, err := epay.ParseNotifyParams(r)
+	if err != nil {
+		log.Printf("解析回调参数失败: %v", err)
+		w.Write([]byte("fail"))
+		return
+	}
 
 	log.Printf("收到支付回调: %+v", params)
 
@@ -270,7 +275,11 @@ func listOrdersHandler(w http.ResponseWriter, r *http.Request) {
 // HTML 模板已通过 embed 嵌入，使用 %s 占位符，分别对应订单号和金额
 func returnHandler(w http.ResponseWriter, r *http.Request) {
 	// 同步跳转，可以验证签名
-	params := epay.ParseNotifyParams(r)
+	params, err := epay.ParseNotifyParams(r)
+	if err != nil {
+		http.Error(w, "参数错误", http.StatusBadRequest)
+		return
+	}
 
 	// 从嵌入的文件系统读取 HTML 模板
 	htmlTemplate, err := templatesFS.ReadFile("templates/return.html")
@@ -0,0 +1,260 @@
+// Package export 提供商户订单导出能力
+// 基于 QueryOrders 分页列表流式导出为 CSV（带 UTF-8 BOM，兼容 Excel）、TSV 和 JSON Lines
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	epay "github.com/liuscraft/epay-sdk-go"
+)
+
+// utf8BOM Excel 识别 UTF-8 编码所需的 BOM
+const utf8BOM = "\xEF\xBB\xBF"
+
+// 导出时间格式
+const timeLayout = "2006-01-02 15:04:05"
+
+// Column 导出列
+type Column string
+
+// 导出列常量
+const (
+	ColumnTradeNo    Column = "trade_no"
+	ColumnOutTradeNo Column = "out_trade_no"
+	ColumnAPITradeNo Column = "api_trade_no"
+	ColumnType       Column = "type"
+	ColumnName       Column = "name"
+	ColumnMoney      Column = "money"
+	ColumnStatus     Column = "status"
+	ColumnAddTime    Column = "addtime"
+	ColumnEndTime    Column = "endtime"
+	ColumnParam      Column = "param"
+	ColumnBuyer      Column = "buyer"
+)
+
+// DefaultColumns 默认导出列
+var DefaultColumns = []Column{
+	ColumnTradeNo, ColumnOutTradeNo, ColumnType, ColumnName,
+	ColumnMoney, ColumnStatus, ColumnAddTime, ColumnEndTime,
+}
+
+// Exporter 订单导出器
+type Exporter struct {
+	client  *epay.Client
+	columns []Column
+	filter  *epay.OrderFilter
+	bom     bool
+}
+
+// Option 配置选项
+type Option func(*Exporter)
+
+// WithColumns 设置导出列及顺序
+func WithColumns(columns ...Column) Option {
+	return func(e *Exporter) {
+		e.columns = columns
+	}
+}
+
+// WithTimeRange 只导出创建时间在 [start, end) 内的订单，零值表示不限制
+func WithTimeRange(start, end time.Time) Option {
+	return func(e *Exporter) {
+		e.filter.Between(start, end)
+	}
+}
+
+// WithFilter 设置完整的订单过滤条件（会覆盖之前的 WithTimeRange）
+// 导出器使用 filter 的副本，之后的选项不会修改调用方的过滤条件；nil 表示不过滤
+func WithFilter(filter *epay.OrderFilter) Option {
+	return func(e *Exporter) {
+		if filter == nil {
+			e.filter = epay.NewOrderFilter()
+			return
+		}
+		e.filter = filter.Clone()
+	}
+}
+
+// WithoutBOM 导出 CSV 时不写入 UTF-8 BOM
+func WithoutBOM() Option {
+	return func(e *Exporter) {
+		e.bom = false
+	}
+}
+
+// NewExporter 创建订单导出器
+// 使用示例:
+//
+//	exporter := export.NewExporter(client,
+//	    export.WithColumns(export.ColumnOutTradeNo, export.ColumnMoney, export.ColumnStatus),
+//	    export.WithTimeRange(start, end),
+//	)
+//	n, err := exporter.WriteCSV(ctx, file)
+func NewExporter(client *epay.Client, opts ...Option) *Exporter {
+	e := &Exporter{
+		client:  client,
+		columns: DefaultColumns,
+		filter:  epay.NewOrderFilter(),
+		bom:     true,
+	}
+
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	return e
+}
+
+// WriteCSV 以 CSV 格式流式导出订单，返回导出的订单数
+func (e *Exporter) WriteCSV(ctx context.Context, w io.Writer) (int, error) {
+	if e.bom {
+		if _, err := io.WriteString(w, utf8BOM); err != nil {
+			return 0, err
+		}
+	}
+	return e.writeDelimited(ctx, w, ',')
+}
+
+// WriteTSV 以制表符分隔格式流式导出订单，返回导出的订单数
+func (e *Exporter) WriteTSV(ctx context.Context, w io.Writer) (int, error) {
+	return e.writeDelimited(ctx, w, '\t')
+}
+
+// WriteJSONLines 以 JSON Lines 格式流式导出订单（每行一个 JSON 对象，字段按导出列顺序），返回导出的订单数
+func (e *Exporter) WriteJSONLines(ctx context.Context, w io.Writer) (int, error) {
+	// 预先编码字段名
+	keys := make([][]byte, len(e.columns))
+	for i, column := range e.columns {
+		key, err := json.Marshal(string(column))
+		if err != nil {
+			return 0, err
+		}
+		keys[i] = key
+	}
+
+	var line bytes.Buffer
+	count := 0
+	err := e.client.FilterOrders(ctx, e.filter, func(order *epay.OrderDetail, info *epay.OrderInfo) error {
+		line.Reset()
+		line.WriteByte('{')
+		for i, column := range e.columns {
+			if i > 0 {
+				line.WriteByte(',')
+			}
+			value, err := json.Marshal(e.value(column, order, info))
+			if err != nil {
+				return err
+			}
+			line.Write(keys[i])
+			line.WriteByte(':')
+			line.Write(value)
+		}
+		line.WriteString("}\n")
+
+		if _, err := w.Write(line.Bytes()); err != nil {
+			return err
+		}
+		count++
+		return nil
+	})
+
+	return count, err
+}
+
+// writeDelimited 以指定分隔符流式导出订单
+func (e *Exporter) writeDelimited(ctx context.Context, w io.Writer, comma rune) (int, error) {
+	writer := csv.NewWriter(w)
+	writer.Comma = comma
+
+	header := make([]string, len(e.columns))
+	for i, column := range e.columns {
+		header[i] = string(column)
+	}
+	if err := writer.Write(header); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	record := make([]string, len(e.columns))
+	err := e.client.FilterOrders(ctx, e.filter, func(order *epay.OrderDetail, info *epay.OrderInfo) error {
+		for i, column := range e.columns {
+			record[i] = e.value(column, order, info)
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+		count++
+		return nil
+	})
+
+	writer.Flush()
+	if err != nil {
+		return count, err
+	}
+	return count, writer.Error()
+}
+
+// value 获取订单指定列的导出值
+func (e *Exporter) value(column Column, order *epay.OrderDetail, info *epay.OrderInfo) string {
+	switch column {
+	case ColumnTradeNo:
+		return order.TradeNo
+	case ColumnOutTradeNo:
+		return order.OutTradeNo
+	case ColumnAPITradeNo:
+		return order.APITradeNo
+	case ColumnType:
+		return order.Type
+	case ColumnName:
+		return order.Name
+	case ColumnMoney:
+		return info.Money.String()
+	case ColumnStatus:
+		return info.Status.String()
+	case ColumnAddTime:
+		return formatTime(info.AddTime)
+	case ColumnEndTime:
+		return formatTime(info.EndTime)
+	case ColumnParam:
+		return order.Param
+	case ColumnBuyer:
+		return order.Buyer
+	default:
+		return ""
+	}
+}
+
+// formatTime 格式化订单时间，零值返回空字符串
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(timeLayout)
+}
+
+// ParseColumns 将列名列表解析为导出列，未知列名返回错误
+func ParseColumns(names []string) ([]Column, error) {
+	known := make(map[Column]bool)
+	for _, c := range []Column{
+		ColumnTradeNo, ColumnOutTradeNo, ColumnAPITradeNo, ColumnType, ColumnName, ColumnMoney,
+		ColumnStatus, ColumnAddTime, ColumnEndTime, ColumnParam, ColumnBuyer,
+	} {
+		known[c] = true
+	}
+
+	columns := make([]Column, 0, len(names))
+	for i, name := range names {
+		column := Column(name)
+		if !known[column] {
+			return nil, epay.NewError(epay.ErrCodeInvalidParam, fmt.Sprintf("unknown export column #%d: %s", i, name))
+		}
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
@@ -0,0 +1,114 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	epay "github.com/liuscraft/epay-sdk-go"
+)
+
+func newTestClient(t *testing.T) *epay.Client {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"code":1,"orders":[
+			{"trade_no":"T3","out_trade_no":"C","name":"会员, 月卡","money":"30","status":1,"addtime":"2024-01-03 10:00:00"},
+			{"trade_no":"T2","out_trade_no":"B","name":"VIP","money":"20.5","status":0,"addtime":"2024-01-02 10:00:00"},
+			{"trade_no":"T1","out_trade_no":"A","name":"VIP","money":"10.00","status":1,"addtime":"2024-01-01 10:00:00"}
+		]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	return epay.NewQuick(1001, "testkey123", server.URL)
+}
+
+func TestExporter_WriteCSV(t *testing.T) {
+	loc := time.FixedZone("CST", 8*3600)
+	exporter := NewExporter(newTestClient(t),
+		WithColumns(ColumnOutTradeNo, ColumnName, ColumnMoney, ColumnStatus),
+		WithTimeRange(time.Date(2024, 1, 2, 0, 0, 0, 0, loc), time.Time{}),
+	)
+
+	var buf bytes.Buffer
+	n, err := exporter.WriteCSV(context.Background(), &buf)
+	if err != nil {
+		t.Fatalf("WriteCSV() error = %v", err)
+	}
+	if n != 2 {
+		t.Errorf("WriteCSV() exported %d orders, want 2", n)
+	}
+
+	want := "\xEF\xBB\xBFout_trade_no,name,money,status\n" +
+		"C,\"会员, 月卡\",30.00,paid\n" +
+		"B,VIP,20.50,unpaid\n"
+	if buf.String() != want {
+		t.Errorf("WriteCSV() = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestExporter_WriteJSONLines(t *testing.T) {
+	exporter := NewExporter(newTestClient(t), WithColumns(ColumnOutTradeNo, ColumnMoney))
+
+	var buf bytes.Buffer
+	n, err := exporter.WriteJSONLines(context.Background(), &buf)
+	if err != nil || n != 3 {
+		t.Fatalf("WriteJSONLines() = %d, %v", n, err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	var record map[string]string
+	if err := json.Unmarshal([]byte(lines[2]), &record); err != nil {
+		t.Fatalf("invalid JSON line %q: %v", lines[2], err)
+	}
+	if record["out_trade_no"] != "A" || record["money"] != epay.FormatMoney(10) {
+		t.Errorf("record = %v", record)
+	}
+
+	// 字段按导出列顺序输出
+	if !strings.HasPrefix(lines[2], `{"out_trade_no":"A","money":`) {
+		t.Errorf("line = %s, want columns in order", lines[2])
+	}
+
+	buf.Reset()
+	exporter = NewExporter(newTestClient(t), WithColumns(ColumnMoney, ColumnOutTradeNo))
+	if _, err := exporter.WriteJSONLines(context.Background(), &buf); err != nil {
+		t.Fatalf("WriteJSONLines() error = %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), `{"money":`) {
+		t.Errorf("WriteJSONLines() = %s, want money first", buf.String())
+	}
+}
+
+func TestExporter_FilterNotModified(t *testing.T) {
+	filter := epay.NewOrderFilter().Status(epay.OrderStatusPaid)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	exporter := NewExporter(newTestClient(t), WithFilter(filter), WithTimeRange(start, time.Time{}))
+
+	if !filter.Match(&epay.OrderInfo{Status: epay.OrderStatusPaid}) {
+		t.Error("WithTimeRange() modified the caller's filter")
+	}
+	if exporter.filter.Match(&epay.OrderInfo{Status: epay.OrderStatusPaid}) {
+		t.Error("exporter filter should apply the time range")
+	}
+
+	// WithFilter(nil) 后仍可设置时间范围
+	exporter = NewExporter(newTestClient(t), WithFilter(nil), WithTimeRange(start, time.Time{}))
+	if exporter.filter == nil {
+		t.Fatal("filter = nil")
+	}
+}
+
+func TestParseColumns(t *testing.T) {
+	if _, err := ParseColumns([]string{"trade_no", "money"}); err != nil {
+		t.Errorf("ParseColumns() error = %v", err)
+	}
+	if _, err := ParseColumns([]string{"unknown"}); err == nil {
+		t.Error("ParseColumns() should fail for unknown column")
+	}
+}
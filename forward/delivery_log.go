@@ -0,0 +1,74 @@
+package forward
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// 内存投递日志默认保留的记录数
+const defaultDeliveryLogLimit = 1000
+
+// Delivery 一次投递尝试的记录
+type Delivery struct {
+	EventID    string        `json:"event_id"`              // 事件ID
+	Endpoint   string        `json:"endpoint"`              // 目标地址
+	Attempt    int           `json:"attempt"`               // 第几次尝试（从 1 开始）
+	StatusCode int           `json:"status_code,omitempty"` // 响应状态码，请求未完成时为 0
+	Error      string        `json:"error,omitempty"`       // 失败原因，成功时为空
+	Duration   time.Duration `json:"duration"`              // 请求耗时
+	SentAt     time.Time     `json:"sent_at"`               // 发送时间
+}
+
+// OK 投递是否成功
+func (d *Delivery) OK() bool {
+	return d.Error == ""
+}
+
+// DeliveryLog 投递日志
+type DeliveryLog interface {
+	// Record 记录一次投递尝试
+	Record(ctx context.Context, delivery *Delivery) error
+}
+
+// MemoryDeliveryLog 基于内存的投递日志，只保留最近的记录
+type MemoryDeliveryLog struct {
+	mu         sync.Mutex
+	deliveries []Delivery
+	limit      int
+}
+
+// NewMemoryDeliveryLog 创建内存投递日志
+// limit 为保留的最大记录数，<= 0 时使用默认值 1000
+func NewMemoryDeliveryLog(limit int) *MemoryDeliveryLog {
+	if limit <= 0 {
+		limit = defaultDeliveryLogLimit
+	}
+	return &MemoryDeliveryLog{limit: limit}
+}
+
+// Record 记录一次投递尝试
+func (l *MemoryDeliveryLog) Record(ctx context.Context, delivery *Delivery) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.deliveries = append(l.deliveries, *delivery)
+	if over := len(l.deliveries) - l.limit; over > 0 {
+		l.deliveries = append(l.deliveries[:0:0], l.deliveries[over:]...)
+	}
+	return nil
+}
+
+// List 按发送顺序列出投递记录，eventID 为空时返回全部
+func (l *MemoryDeliveryLog) List(eventID string) []Delivery {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	result := make([]Delivery, 0, len(l.deliveries))
+	for _, d := range l.deliveries {
+		if eventID == "" || d.EventID == eventID {
+			result = append(result, d)
+		}
+	}
+	return result
+}
@@ -0,0 +1,69 @@
+package forward
+
+import (
+	"time"
+
+	epay "github.com/liuscraft/epay-sdk-go"
+)
+
+// 事件类型
+const (
+	EventPaymentSucceeded = "payment.succeeded" // 支付成功
+	EventPaymentRefunded  = "payment.refunded"  // 订单退款
+	EventPaymentUnknown   = "payment.unknown"   // 未识别的订单状态
+)
+
+// Event 转发给内部服务的标准化回调事件
+type Event struct {
+	ID             string    `json:"id"`                        // 事件ID，同一笔回调重复转发时不变，可用于去重
+	Type           string    `json:"type"`                      // 事件类型
+	PID            int       `json:"pid"`                       // 商户ID
+	TradeNo        string    `json:"trade_no"`                  // EPay订单号
+	OutTradeNo     string    `json:"out_trade_no"`              // 商户订单号
+	PayType        string    `json:"pay_type"`                  // 支付方式
+	Name           string    `json:"name"`                      // 商品名称
+	Money          string    `json:"money"`                     // 金额（元，两位小数）
+	MoneyFen       int64     `json:"money_fen"`                 // 金额（分）
+	TradeStatus    string    `json:"trade_status"`              // 原始订单状态
+	Param          string    `json:"param,omitempty"`           // 业务扩展参数
+	RefundRequired bool      `json:"refund_required,omitempty"` // 订单已取消但收到支付成功通知，需要退款
+	OccurredAt     time.Time `json:"occurred_at"`               // 回调接收时间
+}
+
+// NewEvent 根据验签后的回调数据创建事件
+func NewEvent(data *epay.NotifyData) *Event {
+	event := &Event{
+		ID:             data.TradeNo + "/" + data.TradeStatus,
+		Type:           eventType(data.TradeStatus),
+		PID:            data.PID,
+		TradeNo:        data.TradeNo,
+		OutTradeNo:     data.OutTradeNo,
+		PayType:        data.Type,
+		Name:           data.Name,
+		Money:          data.Money,
+		TradeStatus:    data.TradeStatus,
+		Param:          data.Param,
+		RefundRequired: data.RefundRequired,
+		OccurredAt:     time.Now(),
+	}
+
+	// 统一金额格式
+	if money, err := epay.ParseAmount(data.Money); err == nil {
+		event.Money = money.String()
+		event.MoneyFen = money.Fen()
+	}
+
+	return event
+}
+
+// eventType 订单状态映射为事件类型
+func eventType(status string) string {
+	switch status {
+	case epay.TradeStatusSuccess:
+		return EventPaymentSucceeded
+	case epay.TradeStatusRefund:
+		return EventPaymentRefunded
+	default:
+		return EventPaymentUnknown
+	}
+}
@@ -0,0 +1,314 @@
+// Package forward 将验签后的 EPay 回调转发给内部服务
+// 只有一个服务持有 EPay 回调地址时，可将回调标准化为 JSON 事件，
+// 使用自有 HMAC 密钥签名后 POST 到多个内部地址，失败自动重试并记录投递日志。
+//
+// 使用示例:
+//
+//	fwd := forward.New(secret, []string{"http://billing.internal/epay", "http://crm.internal/epay"},
+//	    forward.WithDeliveryLog(forward.NewMemoryDeliveryLog(0)),
+//	)
+//	defer fwd.Shutdown(ctx)
+//	handlers := handler.NewHandlers(client, handler.WithForwarder(fwd))
+package forward
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	epay "github.com/liuscraft/epay-sdk-go"
+)
+
+// 默认配置
+const (
+	defaultMaxAttempts = 5
+	defaultBackoff     = 500 * time.Millisecond
+	defaultMaxBackoff  = 30 * time.Second
+	defaultTimeout     = 10 * time.Second
+)
+
+// ErrForwarderClosed 转发器已关闭
+var ErrForwarderClosed = errors.New("forward: forwarder closed")
+
+// Logger 日志接口
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// Forwarder 回调事件转发器
+type Forwarder struct {
+	secret      []byte
+	endpoints   []string
+	client      *http.Client
+	maxAttempts int
+	backoff     time.Duration
+	maxBackoff  time.Duration
+	log         DeliveryLog
+	logger      Logger
+	now         func() time.Time
+
+	mu     sync.Mutex
+	closed bool
+	stop   chan struct{} // Shutdown 时关闭，中止重试等待
+	wg     sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// Option 转发器配置选项
+type Option func(*Forwarder)
+
+// WithHTTPClient 设置发送请求使用的 HTTP 客户端（默认超时 10 秒）
+func WithHTTPClient(client *http.Client) Option {
+	return func(f *Forwarder) {
+		f.client = client
+	}
+}
+
+// WithMaxAttempts 设置每个地址的最大投递次数（默认 5 次）
+func WithMaxAttempts(n int) Option {
+	return func(f *Forwarder) {
+		if n > 0 {
+			f.maxAttempts = n
+		}
+	}
+}
+
+// WithBackoff 设置重试间隔，第 n 次重试等待 base*2^(n-1)，不超过 max
+func WithBackoff(base, max time.Duration) Option {
+	return func(f *Forwarder) {
+		if base > 0 {
+			f.backoff = base
+		}
+		if max > 0 {
+			f.maxBackoff = max
+		}
+	}
+}
+
+// WithDeliveryLog 设置投递日志，每次投递尝试都会记录
+func WithDeliveryLog(log DeliveryLog) Option {
+	return func(f *Forwarder) {
+		f.log = log
+	}
+}
+
+// WithLogger 设置自定义日志器
+func WithLogger(logger Logger) Option {
+	return func(f *Forwarder) {
+		f.logger = logger
+	}
+}
+
+// New 创建转发器
+// secret 为与内部服务约定的 HMAC 密钥，endpoints 为接收事件的内部地址
+func New(secret []byte, endpoints []string, opts ...Option) *Forwarder {
+	f := &Forwarder{
+		secret:      secret,
+		endpoints:   append([]string(nil), endpoints...),
+		client:      &http.Client{Timeout: defaultTimeout},
+		maxAttempts: defaultMaxAttempts,
+		backoff:     defaultBackoff,
+		maxBackoff:  defaultMaxBackoff,
+		logger:      log.Default(),
+		now:         time.Now,
+		stop:        make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(f)
+	}
+
+	f.ctx, f.cancel = context.WithCancel(context.Background())
+	return f
+}
+
+// Forward 同步转发回调数据到所有地址
+// 各地址并发投递并独立重试，返回所有最终失败地址的错误
+func (f *Forwarder) Forward(ctx context.Context, data *epay.NotifyData) error {
+	return f.Send(ctx, NewEvent(data))
+}
+
+// ForwardAsync 在后台转发回调数据，不阻塞回调应答
+// 投递结果写入投递日志，Shutdown 会等待后台投递完成
+func (f *Forwarder) ForwardAsync(data *epay.NotifyData) error {
+	event := NewEvent(data)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return ErrForwarderClosed
+	}
+
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+		if err := f.Send(f.ctx, event); err != nil {
+			f.logger.Printf("Forward event %s failed: %v", event.ID, err)
+		}
+	}()
+	return nil
+}
+
+// Send 同步发送事件到所有地址
+func (f *Forwarder) Send(ctx context.Context, event *Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("forward: encode event: %w", err)
+	}
+
+	errs := make([]error, len(f.endpoints))
+	var wg sync.WaitGroup
+	for i, endpoint := range f.endpoints {
+		wg.Add(1)
+		go func(i int, endpoint string) {
+			defer wg.Done()
+			errs[i] = f.deliver(ctx, endpoint, event.ID, body)
+		}(i, endpoint)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// Shutdown 停止接收新的后台转发，取消等待中的重试，并等待进行中的请求完成
+// ctx 超时后取消进行中的请求并返回 ctx.Err()
+func (f *Forwarder) Shutdown(ctx context.Context) error {
+	f.mu.Lock()
+	if !f.closed {
+		f.closed = true
+		close(f.stop)
+	}
+	f.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		f.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		f.cancel()
+		return nil
+	case <-ctx.Done():
+		f.cancel()
+		return ctx.Err()
+	}
+}
+
+// deliver 向单个地址投递事件，失败时按退避策略重试
+func (f *Forwarder) deliver(ctx context.Context, endpoint, eventID string, body []byte) error {
+	var lastErr error
+	for attempt := 1; attempt <= f.maxAttempts; attempt++ {
+		if attempt > 1 {
+			if err := f.sleep(ctx, f.backoffFor(attempt-1)); err != nil {
+				return fmt.Errorf("forward %s to %s: %w (last error: %v)", eventID, endpoint, err, lastErr)
+			}
+		}
+
+		retry, err := f.attempt(ctx, endpoint, eventID, attempt, body)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if !retry {
+			break
+		}
+	}
+	return fmt.Errorf("forward %s to %s: %w", eventID, endpoint, lastErr)
+}
+
+// attempt 发送一次请求并记录投递日志，返回失败时是否值得重试
+func (f *Forwarder) attempt(ctx context.Context, endpoint, eventID string, attempt int, body []byte) (bool, error) {
+	sentAt := f.now()
+	delivery := &Delivery{
+		EventID:  eventID,
+		Endpoint: endpoint,
+		Attempt:  attempt,
+		SentAt:   sentAt,
+	}
+
+	retry, err := f.post(ctx, endpoint, eventID, body, sentAt, delivery)
+	delivery.Duration = f.now().Sub(sentAt)
+	if err != nil {
+		delivery.Error = err.Error()
+	}
+
+	if f.log != nil {
+		if logErr := f.log.Record(ctx, delivery); logErr != nil {
+			f.logger.Printf("Record delivery of event %s to %s failed: %v", eventID, endpoint, logErr)
+		}
+	}
+	return retry, err
+}
+
+// post 构造签名请求并发送
+func (f *Forwarder) post(ctx context.Context, endpoint, eventID string, body []byte, sentAt time.Time, delivery *Delivery) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+
+	timestamp := sentAt.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEventID, eventID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(f.secret, timestamp, body))
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	delivery.StatusCode = resp.StatusCode
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	return retryableStatus(resp.StatusCode), fmt.Errorf("unexpected status %d", resp.StatusCode)
+}
+
+// backoffFor 第 n 次重试前的等待时间
+func (f *Forwarder) backoffFor(n int) time.Duration {
+	d := f.backoff
+	for i := 1; i < n && d < f.maxBackoff; i++ {
+		d *= 2
+	}
+	if d > f.maxBackoff {
+		d = f.maxBackoff
+	}
+	return d
+}
+
+// retryableStatus 状态码是否值得重试
+// 4xx 表示请求本身有误（如签名密钥不一致），重试无意义；408 和 429 除外
+func retryableStatus(code int) bool {
+	if code == http.StatusRequestTimeout || code == http.StatusTooManyRequests {
+		return true
+	}
+	return code < 400 || code >= 500
+}
+
+// sleep 等待重试间隔，ctx 取消或转发器关闭时提前返回
+func (f *Forwarder) sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-f.stop:
+		return ErrForwarderClosed
+	}
+}
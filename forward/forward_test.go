@@ -0,0 +1,296 @@
+package forward
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	epay "github.com/liuscraft/epay-sdk-go"
+)
+
+var testSecret = []byte("internal-secret")
+
+func testNotify() *epay.NotifyData {
+	return &epay.NotifyData{
+		PID:         1001,
+		TradeNo:     "T20240101",
+		OutTradeNo:  "ORDER001",
+		Type:        "alipay",
+		Name:        "VIP",
+		Money:       "9.9",
+		TradeStatus: epay.TradeStatusSuccess,
+	}
+}
+
+// receiver 校验签名并记录收到的事件
+type receiver struct {
+	mu     sync.Mutex
+	events []*Event
+	fail   atomic.Int32 // 前 n 次请求返回的失败次数
+	status int
+}
+
+func (rv *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if rv.fail.Add(-1) >= 0 {
+		w.WriteHeader(rv.status)
+		return
+	}
+	event, err := ParseRequest(r, testSecret, 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	rv.mu.Lock()
+	rv.events = append(rv.events, event)
+	rv.mu.Unlock()
+}
+
+func (rv *receiver) received() []*Event {
+	rv.mu.Lock()
+	defer rv.mu.Unlock()
+	return append([]*Event(nil), rv.events...)
+}
+
+func TestNewEvent(t *testing.T) {
+	event := NewEvent(testNotify())
+	if event.ID != "T20240101/TRADE_SUCCESS" {
+		t.Errorf("ID = %q", event.ID)
+	}
+	if event.Type != EventPaymentSucceeded {
+		t.Errorf("Type = %q", event.Type)
+	}
+	if event.Money != "9.90" || event.MoneyFen != 990 {
+		t.Errorf("Money = %q, MoneyFen = %d", event.Money, event.MoneyFen)
+	}
+	if event.PayType != "alipay" || event.OutTradeNo != "ORDER001" {
+		t.Errorf("unexpected event: %+v", event)
+	}
+
+	refund := testNotify()
+	refund.TradeStatus = epay.TradeStatusRefund
+	if got := NewEvent(refund).Type; got != EventPaymentRefunded {
+		t.Errorf("refund Type = %q", got)
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	body := []byte(`{"id":"1"}`)
+	now := time.Unix(1700000000, 0)
+	sig := Sign(testSecret, now.Unix(), body)
+	ts := "1700000000"
+
+	tests := []struct {
+		name    string
+		secret  []byte
+		ts      string
+		sig     string
+		body    []byte
+		now     time.Time
+		wantErr error
+	}{
+		{"valid", testSecret, ts, sig, body, now, nil},
+		{"clock skew within tolerance", testSecret, ts, sig, body, now.Add(4 * time.Minute), nil},
+		{"expired", testSecret, ts, sig, body, now.Add(10 * time.Minute), ErrTimestampExpired},
+		{"wrong secret", []byte("other"), ts, sig, body, now, ErrInvalidSignature},
+		{"tampered body", testSecret, ts, sig, []byte(`{"id":"2"}`), now, ErrInvalidSignature},
+		{"replayed timestamp", testSecret, "1700000001", sig, body, now, ErrInvalidSignature},
+		{"bad timestamp", testSecret, "abc", sig, body, now, ErrInvalidSignature},
+		{"missing signature", testSecret, ts, "", body, now, ErrMissingSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifySignature(tt.secret, tt.ts, tt.sig, tt.body, tt.now, 0)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("VerifySignature() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestForwarder_Forward(t *testing.T) {
+	billing, crm := &receiver{}, &receiver{}
+	billingSrv := httptest.NewServer(billing)
+	defer billingSrv.Close()
+	crmSrv := httptest.NewServer(crm)
+	defer crmSrv.Close()
+
+	deliveries := NewMemoryDeliveryLog(0)
+	fwd := New(testSecret, []string{billingSrv.URL, crmSrv.URL}, WithDeliveryLog(deliveries))
+
+	if err := fwd.Forward(context.Background(), testNotify()); err != nil {
+		t.Fatalf("Forward() error = %v", err)
+	}
+
+	for _, rv := range []*receiver{billing, crm} {
+		events := rv.received()
+		if len(events) != 1 || events[0].OutTradeNo != "ORDER001" || events[0].MoneyFen != 990 {
+			t.Errorf("received = %+v", events)
+		}
+	}
+
+	logged := deliveries.List("T20240101/TRADE_SUCCESS")
+	if len(logged) != 2 {
+		t.Fatalf("delivery log has %d entries, want 2", len(logged))
+	}
+	for _, d := range logged {
+		if !d.OK() || d.StatusCode != http.StatusOK || d.Attempt != 1 {
+			t.Errorf("delivery = %+v", d)
+		}
+	}
+}
+
+func TestForwarder_Retry(t *testing.T) {
+	rv := &receiver{status: http.StatusServiceUnavailable}
+	rv.fail.Store(2)
+	srv := httptest.NewServer(rv)
+	defer srv.Close()
+
+	deliveries := NewMemoryDeliveryLog(0)
+	fwd := New(testSecret, []string{srv.URL},
+		WithDeliveryLog(deliveries),
+		WithBackoff(time.Millisecond, 5*time.Millisecond),
+	)
+
+	if err := fwd.Forward(context.Background(), testNotify()); err != nil {
+		t.Fatalf("Forward() error = %v", err)
+	}
+	if got := len(rv.received()); got != 1 {
+		t.Errorf("received %d events, want 1", got)
+	}
+
+	logged := deliveries.List("")
+	if len(logged) != 3 {
+		t.Fatalf("delivery log has %d entries, want 3", len(logged))
+	}
+	if logged[0].OK() || logged[0].StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("first attempt = %+v", logged[0])
+	}
+	if !logged[2].OK() || logged[2].Attempt != 3 {
+		t.Errorf("last attempt = %+v", logged[2])
+	}
+}
+
+func TestForwarder_GiveUp(t *testing.T) {
+	// 4xx 不重试
+	rejected := &receiver{status: http.StatusBadRequest}
+	rejected.fail.Store(100)
+	rejectedSrv := httptest.NewServer(rejected)
+	defer rejectedSrv.Close()
+
+	// 5xx 重试到上限
+	broken := &receiver{status: http.StatusBadGateway}
+	broken.fail.Store(100)
+	brokenSrv := httptest.NewServer(broken)
+	defer brokenSrv.Close()
+
+	deliveries := NewMemoryDeliveryLog(0)
+	fwd := New(testSecret, []string{rejectedSrv.URL, brokenSrv.URL},
+		WithDeliveryLog(deliveries),
+		WithMaxAttempts(3),
+		WithBackoff(time.Millisecond, time.Millisecond),
+	)
+
+	err := fwd.Forward(context.Background(), testNotify())
+	if err == nil {
+		t.Fatal("Forward() error = nil, want error")
+	}
+	if !strings.Contains(err.Error(), rejectedSrv.URL) || !strings.Contains(err.Error(), brokenSrv.URL) {
+		t.Errorf("error should name both endpoints: %v", err)
+	}
+
+	attempts := map[string]int{}
+	for _, d := range deliveries.List("") {
+		attempts[d.Endpoint]++
+	}
+	if attempts[rejectedSrv.URL] != 1 || attempts[brokenSrv.URL] != 3 {
+		t.Errorf("attempts = %v", attempts)
+	}
+}
+
+func TestForwarder_ForwardAsync(t *testing.T) {
+	rv := &receiver{}
+	srv := httptest.NewServer(rv)
+	defer srv.Close()
+
+	fwd := New(testSecret, []string{srv.URL})
+	if err := fwd.ForwardAsync(testNotify()); err != nil {
+		t.Fatalf("ForwardAsync() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := fwd.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if got := len(rv.received()); got != 1 {
+		t.Errorf("received %d events, want 1", got)
+	}
+
+	if err := fwd.ForwardAsync(testNotify()); !errors.Is(err, ErrForwarderClosed) {
+		t.Errorf("ForwardAsync() after Shutdown error = %v, want ErrForwarderClosed", err)
+	}
+}
+
+func TestMemoryDeliveryLog_Limit(t *testing.T) {
+	deliveries := NewMemoryDeliveryLog(2)
+	for i := 1; i <= 3; i++ {
+		deliveries.Record(context.Background(), &Delivery{EventID: "e", Attempt: i})
+	}
+
+	logged := deliveries.List("e")
+	if len(logged) != 2 || logged[0].Attempt != 2 || logged[1].Attempt != 3 {
+		t.Errorf("List() = %+v", logged)
+	}
+}
+
+func TestForwarder_ShutdownCancelsBackoff(t *testing.T) {
+	rv := &receiver{status: http.StatusServiceUnavailable}
+	rv.fail.Store(100)
+	srv := httptest.NewServer(rv)
+	defer srv.Close()
+
+	deliveries := NewMemoryDeliveryLog(0)
+	fwd := New(testSecret, []string{srv.URL},
+		WithBackoff(time.Hour, time.Hour),
+		WithDeliveryLog(deliveries),
+		WithLogger(discardLogger{}),
+	)
+	if err := fwd.ForwardAsync(testNotify()); err != nil {
+		t.Fatalf("ForwardAsync() error = %v", err)
+	}
+
+	// 等待第一次投递失败进入退避
+	deadline := time.Now().Add(5 * time.Second)
+	for len(deliveries.List(NewEvent(testNotify()).ID)) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("first delivery not recorded")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// 退避等待被立即中止，不必等到 ctx 超时
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	start := time.Now()
+	if err := fwd.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Shutdown() took %v, want backoff cancelled immediately", elapsed)
+	}
+	if n := len(deliveries.List(NewEvent(testNotify()).ID)); n != 1 {
+		t.Errorf("deliveries = %d, want 1", n)
+	}
+}
+
+// discardLogger 丢弃日志
+type discardLogger struct{}
+
+func (discardLogger) Printf(format string, v ...interface{}) {}
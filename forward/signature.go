@@ -0,0 +1,108 @@
+package forward
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 转发请求头
+const (
+	HeaderEventID   = "X-EPay-Event-Id"  // 事件ID
+	HeaderTimestamp = "X-EPay-Timestamp" // 签名时间戳（Unix 秒）
+	HeaderSignature = "X-EPay-Signature" // 签名，格式为 "sha256=<hex>"
+)
+
+// DefaultTolerance 接收方默认允许的签名时间偏差
+const DefaultTolerance = 5 * time.Minute
+
+// 接收方允许的最大请求体
+const maxEventBodySize = 1 << 20
+
+// 签名校验错误
+var (
+	ErrMissingSignature = errors.New("forward: missing signature")
+	ErrInvalidSignature = errors.New("forward: invalid signature")
+	ErrTimestampExpired = errors.New("forward: timestamp outside tolerance")
+)
+
+// Sign 计算转发请求签名
+// 签名内容为 "<timestamp>.<body>"，使用 HMAC-SHA256，返回 "sha256=<hex>"
+func Sign(secret []byte, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature 校验转发请求签名和时间戳
+// tolerance 为 0 时使用 DefaultTolerance
+func VerifySignature(secret []byte, timestamp, signature string, body []byte, now time.Time, tolerance time.Duration) error {
+	if timestamp == "" || signature == "" {
+		return ErrMissingSignature
+	}
+	if tolerance <= 0 {
+		tolerance = DefaultTolerance
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: bad timestamp %q", ErrInvalidSignature, timestamp)
+	}
+	diff := now.Sub(time.Unix(ts, 0))
+	if diff < 0 {
+		diff = -diff
+	}
+	if diff > tolerance {
+		return ErrTimestampExpired
+	}
+
+	if !strings.HasPrefix(signature, "sha256=") {
+		return ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(Sign(secret, ts, body)), []byte(signature)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// ParseRequest 供内部服务校验并解析转发请求
+// 使用示例:
+//
+//	http.HandleFunc("/internal/epay", func(w http.ResponseWriter, r *http.Request) {
+//	    event, err := forward.ParseRequest(r, secret, 0)
+//	    if err != nil {
+//	        http.Error(w, err.Error(), http.StatusUnauthorized)
+//	        return
+//	    }
+//	    // 按 event.ID 去重后处理
+//	})
+func ParseRequest(r *http.Request, secret []byte, tolerance time.Duration) (*Event, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxEventBodySize+1))
+	if err != nil {
+		return nil, fmt.Errorf("forward: read body: %w", err)
+	}
+	if len(body) > maxEventBodySize {
+		return nil, fmt.Errorf("forward: body exceeds %d bytes", maxEventBodySize)
+	}
+
+	err = VerifySignature(secret, r.Header.Get(HeaderTimestamp), r.Header.Get(HeaderSignature), body, time.Now(), tolerance)
+	if err != nil {
+		return nil, err
+	}
+
+	var event Event
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, fmt.Errorf("forward: decode event: %w", err)
+	}
+	return &event, nil
+}
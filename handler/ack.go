@@ -0,0 +1,100 @@
+package handler
+
+import "net/http"
+
+// NotifyAck 回调应答协议
+// 决定 Notify 处理完成后向支付网关返回的状态码和响应内容
+type NotifyAck interface {
+	// WriteAck 写入应答，ok 表示回调是否处理成功
+	WriteAck(w http.ResponseWriter, ok bool)
+}
+
+// Ack 基于固定状态码和响应体的回调应答
+type Ack struct {
+	ContentType   string // 响应 Content-Type，为空时使用 text/plain
+	SuccessStatus int    // 成功状态码，为 0 时使用 200
+	SuccessBody   string // 成功响应体
+	FailStatus    int    // 失败状态码，为 0 时使用 200
+	FailBody      string // 失败响应体
+}
+
+// 内置应答预设
+var (
+	// AckPlain 标准 EPay 应答：小写 "success"/"fail"，状态码均为 200（默认）
+	AckPlain = Ack{SuccessBody: "success", FailBody: "fail"}
+
+	// AckUpper 大写应答：返回 "SUCCESS"/"FAIL"，部分衍生版本按大写判断
+	AckUpper = Ack{SuccessBody: "SUCCESS", FailBody: "FAIL"}
+
+	// AckJSON JSON 应答：返回 {"code":0,"msg":"success"}/{"code":1,"msg":"fail"}
+	AckJSON = Ack{
+		ContentType: "application/json; charset=utf-8",
+		SuccessBody: `{"code":0,"msg":"success"}`,
+		FailBody:    `{"code":1,"msg":"fail"}`,
+	}
+
+	// AckStatus 状态码应答：失败时返回 500，适用于仅按 HTTP 状态码判断是否重试的网关
+	AckStatus = Ack{SuccessBody: "success", FailStatus: http.StatusInternalServerError, FailBody: "fail"}
+)
+
+// ackPresets 按名称查找的应答预设
+var ackPresets = map[string]Ack{
+	"plain":  AckPlain,
+	"upper":  AckUpper,
+	"json":   AckJSON,
+	"status": AckStatus,
+}
+
+// AckPreset 按名称获取内置应答预设（plain, upper, json, status）
+// 便于从配置文件选择应答协议，名称不存在时返回 false
+func AckPreset(name string) (Ack, bool) {
+	ack, ok := ackPresets[name]
+	return ack, ok
+}
+
+// WriteAck 实现 NotifyAck 接口
+func (a Ack) WriteAck(w http.ResponseWriter, ok bool) {
+	status, body := a.SuccessStatus, a.SuccessBody
+	if !ok {
+		status, body = a.FailStatus, a.FailBody
+	}
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	contentType := a.ContentType
+	if contentType == "" {
+		contentType = "text/plain; charset=utf-8"
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	w.Write([]byte(body))
+}
+
+// NotifyAckFunc 函数形式的回调应答
+type NotifyAckFunc func(w http.ResponseWriter, ok bool)
+
+// WriteAck 实现 NotifyAck 接口
+func (f NotifyAckFunc) WriteAck(w http.ResponseWriter, ok bool) {
+	f(w, ok)
+}
+
+// WithNotifyAck 设置回调应答协议，默认使用 AckPlain
+// 每个 Handlers 对应一个客户端，可按网关分别配置，例如:
+//
+//	handler.NewHandlers(client, handler.WithNotifyAck(handler.AckJSON))
+func WithNotifyAck(ack NotifyAck) Option {
+	return func(h *Handlers) {
+		h.ack = ack
+	}
+}
+
+// writeAck 按配置的应答协议写入回调应答
+func (h *Handlers) writeAck(w http.ResponseWriter, ok bool) {
+	ack := h.ack
+	if ack == nil {
+		ack = AckPlain
+	}
+	ack.WriteAck(w, ok)
+}
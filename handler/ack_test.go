@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/liuscraft/epay-sdk-go/inbox"
+)
+
+// ackCase 应答预设的期望结果
+type ackCase struct {
+	preset      string
+	contentType string
+	okStatus    int
+	okBody      string
+	failStatus  int
+	failBody    string
+}
+
+var ackCases = []ackCase{
+	{"plain", "text/plain; charset=utf-8", http.StatusOK, "success", http.StatusOK, "fail"},
+	{"upper", "text/plain; charset=utf-8", http.StatusOK, "SUCCESS", http.StatusOK, "FAIL"},
+	{"json", "application/json; charset=utf-8", http.StatusOK, `{"code":0,"msg":"success"}`, http.StatusOK, `{"code":1,"msg":"fail"}`},
+	{"status", "text/plain; charset=utf-8", http.StatusOK, "success", http.StatusInternalServerError, "fail"},
+}
+
+// checkAck 检查应答的状态码、响应体和 Content-Type
+func checkAck(t *testing.T, rec *httptest.ResponseRecorder, status int, body, contentType string) {
+	t.Helper()
+	if rec.Code != status || rec.Body.String() != body {
+		t.Errorf("response = %d %q, want %d %q", rec.Code, rec.Body.String(), status, body)
+	}
+	if got := rec.Header().Get("Content-Type"); got != contentType {
+		t.Errorf("Content-Type = %q, want %q", got, contentType)
+	}
+}
+
+func TestNotifyAck_Presets(t *testing.T) {
+	for _, tc := range ackCases {
+		t.Run(tc.preset, func(t *testing.T) {
+			ack, ok := AckPreset(tc.preset)
+			if !ok {
+				t.Fatalf("AckPreset(%q) not found", tc.preset)
+			}
+			h, client := newTestHandlers(t, "", WithNotifyAck(ack))
+			notify := h.NotifyFunc(nil)
+
+			values := signedNotify(client, "T1", "A", "1.00")
+			checkAck(t, sendNotify(notify, values), tc.okStatus, tc.okBody, tc.contentType)
+
+			values.Set("money", "100.00")
+			checkAck(t, sendNotify(notify, values), tc.failStatus, tc.failBody, tc.contentType)
+		})
+	}
+
+	if _, ok := AckPreset("unknown"); ok {
+		t.Error("AckPreset(unknown) should not be found")
+	}
+}
+
+func TestNotifyAck_Default(t *testing.T) {
+	h, client := newTestHandlers(t, "")
+	notify := h.NotifyFunc(nil)
+
+	values := signedNotify(client, "T1", "A", "1.00")
+	checkAck(t, sendNotify(notify, values), http.StatusOK, "success", "text/plain; charset=utf-8")
+	values.Set("sign", "bad")
+	checkAck(t, sendNotify(notify, values), http.StatusOK, "fail", "text/plain; charset=utf-8")
+}
+
+func TestNotifyAck_Func(t *testing.T) {
+	ack := NotifyAckFunc(func(w http.ResponseWriter, ok bool) {
+		if ok {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.WriteHeader(http.StatusTeapot)
+	})
+	h, client := newTestHandlers(t, "", WithNotifyAck(ack))
+	notify := h.NotifyFunc(nil)
+
+	values := signedNotify(client, "T1", "A", "1.00")
+	if rec := sendNotify(notify, values); rec.Code != http.StatusNoContent {
+		t.Errorf("success status = %d, want 204", rec.Code)
+	}
+	values.Set("sign", "bad")
+	if rec := sendNotify(notify, values); rec.Code != http.StatusTeapot {
+		t.Errorf("fail status = %d, want 418", rec.Code)
+	}
+}
+
+func TestInboxNotify_Ack(t *testing.T) {
+	for _, tc := range ackCases {
+		t.Run(tc.preset, func(t *testing.T) {
+			ack, _ := AckPreset(tc.preset)
+			h, client := newTestHandlers(t, "", WithNotifyAck(ack))
+			queue := inbox.NewMemoryQueue()
+			notify := h.NewInbox(queue, nil).Notify()
+
+			// 写入队列后使用配置的成功应答
+			values := signedNotify(client, "T1", "A", "1.00")
+			checkAck(t, sendNotify(notify, values), tc.okStatus, tc.okBody, tc.contentType)
+			if queue.Len() != 1 {
+				t.Errorf("queue Len() = %d, want 1", queue.Len())
+			}
+
+			// 验签失败使用配置的失败应答，且不入队
+			values.Set("sign", "bad")
+			checkAck(t, sendNotify(notify, values), tc.failStatus, tc.failBody, tc.contentType)
+			if queue.Len() != 1 {
+				t.Errorf("queue Len() = %d, want 1", queue.Len())
+			}
+		})
+	}
+}
+
+// failingQueue 写入总是失败的队列
+type failingQueue struct {
+	inbox.Queue
+}
+
+func (failingQueue) Enqueue(ctx context.Context, msg *inbox.Message) error {
+	return context.DeadlineExceeded
+}
+
+func TestInboxNotify_EnqueueFailure(t *testing.T) {
+	h, client := newTestHandlers(t, "", WithNotifyAck(AckStatus))
+	notify := h.NewInbox(failingQueue{inbox.NewMemoryQueue()}, nil).Notify()
+
+	rec := sendNotify(notify, signedNotify(client, "T1", "A", "1.00"))
+	checkAck(t, rec, http.StatusInternalServerError, "fail", "text/plain; charset=utf-8")
+}
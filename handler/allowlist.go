@@ -0,0 +1,216 @@
+package handler
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+)
+
+// 按来源统计被拒绝回调时最多记录的地址数，超出部分计入 "other"
+const maxRejectedSources = 1024
+
+// WithNotifyAllowlist 限制回调来源 IP，只接受 EPay 服务商出口 IP 发来的回调
+// 参数为 CIDR（如 "203.0.113.0/24"）或单个 IP，格式错误时 panic
+// 不在白名单内的请求返回 403，不会验签和执行业务回调
+func WithNotifyAllowlist(cidrs ...string) Option {
+	prefixes := mustParsePrefixes("notify allowlist", cidrs)
+	return func(h *Handlers) {
+		h.allowlist = prefixes
+	}
+}
+
+// WithTrustedProxies 设置可信反向代理地址（CIDR 或单个 IP），格式错误时 panic
+// 只有直连地址属于可信代理时，才会从 X-Forwarded-For / X-Real-IP 中提取客户端 IP
+func WithTrustedProxies(cidrs ...string) Option {
+	prefixes := mustParsePrefixes("trusted proxies", cidrs)
+	return func(h *Handlers) {
+		h.trustedProxies = prefixes
+	}
+}
+
+// WithNotifyRejectHook 设置回调来源被拒绝时的钩子，可用于上报监控指标
+func WithNotifyRejectHook(hook func(ip string, r *http.Request)) Option {
+	return func(h *Handlers) {
+		h.rejectHook = hook
+	}
+}
+
+// RejectionStats 被拒绝的回调来源统计
+type RejectionStats struct {
+	Total    uint64            // 拒绝总数
+	BySource map[string]uint64 // 按来源 IP 统计
+}
+
+// NotifyRejections 返回因来源 IP 不在白名单而被拒绝的回调统计
+func (h *Handlers) NotifyRejections() RejectionStats {
+	return h.rejections.snapshot()
+}
+
+// ClientIP 返回请求的客户端 IP
+// 从直连地址开始，沿 X-Forwarded-For 从右向左跳过可信代理，返回第一个不可信的地址；
+// 没有 X-Forwarded-For 时使用可信代理设置的 X-Real-IP。
+// IPv4 映射的 IPv6 地址（::ffff:1.2.3.4）按 IPv4 返回
+func (h *Handlers) ClientIP(r *http.Request) string {
+	remote := normalizeIP(remoteIP(r.RemoteAddr))
+	if !h.isTrustedProxy(remote) {
+		return remote
+	}
+
+	if xff := r.Header.Values("X-Forwarded-For"); len(xff) > 0 {
+		hops := strings.Split(strings.Join(xff, ","), ",")
+		client := remote
+		for i := len(hops) - 1; i >= 0; i-- {
+			addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+			if err != nil {
+				// 无法解析的地址不可信，停在上一个地址
+				break
+			}
+			client = addr.Unmap().String()
+			if !h.isTrustedProxy(client) {
+				break
+			}
+		}
+		return client
+	}
+
+	if realIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); realIP != "" {
+		if addr, err := netip.ParseAddr(realIP); err == nil {
+			return addr.Unmap().String()
+		}
+	}
+	return remote
+}
+
+// allowNotifySource 检查回调来源是否在白名单内，不在时返回 403 并计数
+func (h *Handlers) allowNotifySource(w http.ResponseWriter, r *http.Request) bool {
+	if len(h.allowlist) == 0 {
+		return true
+	}
+
+	ip := h.ClientIP(r)
+	if addr, err := netip.ParseAddr(ip); err == nil && containsAddr(h.allowlist, addr.Unmap()) {
+		return true
+	}
+
+	h.rejections.add(ip)
+	h.logger.Printf("Notify from %s rejected: source not in allowlist (remote_addr=%s)", ip, r.RemoteAddr)
+	if h.rejectHook != nil {
+		h.rejectHook(ip, r)
+	}
+	http.Error(w, "Forbidden", http.StatusForbidden)
+	return false
+}
+
+// isTrustedProxy 判断地址是否为可信代理
+func (h *Handlers) isTrustedProxy(ip string) bool {
+	if len(h.trustedProxies) == 0 {
+		return false
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	return containsAddr(h.trustedProxies, addr.Unmap())
+}
+
+// rejectionCounter 被拒绝来源计数器
+type rejectionCounter struct {
+	mu       sync.Mutex
+	total    uint64
+	bySource map[string]uint64
+}
+
+// add 记录一次拒绝
+func (c *rejectionCounter) add(ip string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.bySource == nil {
+		c.bySource = make(map[string]uint64)
+	}
+	if _, ok := c.bySource[ip]; !ok && len(c.bySource) >= maxRejectedSources {
+		ip = "other"
+	}
+	c.total++
+	c.bySource[ip]++
+}
+
+// snapshot 返回统计快照
+func (c *rejectionCounter) snapshot() RejectionStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := RejectionStats{
+		Total:    c.total,
+		BySource: make(map[string]uint64, len(c.bySource)),
+	}
+	for ip, n := range c.bySource {
+		stats.BySource[ip] = n
+	}
+	return stats
+}
+
+// remoteIP 从 RemoteAddr（host:port）中提取 IP
+func remoteIP(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return host
+}
+
+// normalizeIP 将 IPv4 映射的 IPv6 地址转换为 IPv4，无法解析时原样返回
+func normalizeIP(ip string) string {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return ip
+	}
+	return addr.Unmap().String()
+}
+
+// containsAddr 判断地址是否属于任一网段
+func containsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParsePrefixes 解析 CIDR 或单个 IP 列表
+// 单个 IP 转换为 /32（IPv6 为 /128），IPv4 映射的 IPv6 地址按 IPv4 处理。
+// 白名单来自配置文件时，可先用它校验，避免 WithNotifyAllowlist / WithTrustedProxies panic
+func ParsePrefixes(cidrs ...string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		cidr = strings.TrimSpace(cidr)
+		if strings.Contains(cidr, "/") {
+			prefix, err := netip.ParsePrefix(cidr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid entry %q: %w", cidr, err)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid entry %q: %w", cidr, err)
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+// mustParsePrefixes 解析 CIDR 或单个 IP 列表，格式错误时 panic
+func mustParsePrefixes(name string, cidrs []string) []netip.Prefix {
+	prefixes, err := ParsePrefixes(cidrs...)
+	if err != nil {
+		panic(fmt.Sprintf("handler: %s: %v", name, err))
+	}
+	return prefixes
+}
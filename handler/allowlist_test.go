@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParsePrefixes(t *testing.T) {
+	tests := []struct {
+		name    string
+		cidrs   []string
+		want    []string
+		wantErr bool
+	}{
+		{"cidr", []string{"203.0.113.0/24"}, []string{"203.0.113.0/24"}, false},
+		{"cidr masked", []string{"203.0.113.7/24"}, []string{"203.0.113.0/24"}, false},
+		{"single ipv4", []string{" 198.51.100.1 "}, []string{"198.51.100.1/32"}, false},
+		{"single ipv6", []string{"2001:db8::1"}, []string{"2001:db8::1/128"}, false},
+		{"ipv4-mapped", []string{"::ffff:198.51.100.1"}, []string{"198.51.100.1/32"}, false},
+		{"empty list", nil, []string{}, false},
+		{"invalid ip", []string{"198.51.100.1", "not-an-ip"}, nil, true},
+		{"invalid cidr", []string{"203.0.113.0/33"}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePrefixes(tt.cidrs...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePrefixes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ParsePrefixes() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i].String() != tt.want[i] {
+					t.Errorf("ParsePrefixes()[%d] = %s, want %s", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestWithNotifyAllowlist_InvalidPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("WithNotifyAllowlist() with invalid entry should panic")
+		}
+	}()
+	WithNotifyAllowlist("bad")
+}
+
+func TestClientIP(t *testing.T) {
+	h, _ := newTestHandlers(t, "", WithTrustedProxies("10.0.0.0/8", "2001:db8::/32"))
+
+	tests := []struct {
+		name   string
+		remote string
+		xff    []string
+		realIP string
+		want   string
+	}{
+		{"direct", "198.51.100.1:1234", nil, "", "198.51.100.1"},
+		{"untrusted remote ignores headers", "198.51.100.1:1234", []string{"203.0.113.9"}, "203.0.113.8", "198.51.100.1"},
+		{"trusted proxy xff", "10.0.0.1:1234", []string{"203.0.113.9"}, "", "203.0.113.9"},
+		{"right to left skips trusted hops", "10.0.0.1:1234", []string{"1.1.1.1, 203.0.113.9, 10.0.0.2"}, "", "203.0.113.9"},
+		{"spoofed leftmost entry ignored", "10.0.0.1:1234", []string{"203.0.113.1", "198.51.100.7, 10.0.0.3"}, "", "198.51.100.7"},
+		{"all hops trusted", "10.0.0.1:1234", []string{"10.0.0.3, 10.0.0.2"}, "", "10.0.0.3"},
+		{"unparsable hop stops walk", "10.0.0.1:1234", []string{"203.0.113.9, garbage, 10.0.0.2"}, "", "10.0.0.2"},
+		{"x-real-ip fallback", "10.0.0.1:1234", nil, "203.0.113.8", "203.0.113.8"},
+		{"invalid x-real-ip", "10.0.0.1:1234", nil, "garbage", "10.0.0.1"},
+		{"ipv4-mapped remote", "[::ffff:198.51.100.1]:1234", nil, "", "198.51.100.1"},
+		{"ipv4-mapped trusted proxy", "[::ffff:10.0.0.1]:1234", []string{"::ffff:203.0.113.9"}, "", "203.0.113.9"},
+		{"ipv6 proxy", "[2001:db8::1]:1234", nil, "2001:db8:ffff::1", "2001:db8:ffff::1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/notify", nil)
+			r.RemoteAddr = tt.remote
+			for _, v := range tt.xff {
+				r.Header.Add("X-Forwarded-For", v)
+			}
+			if tt.realIP != "" {
+				r.Header.Set("X-Real-IP", tt.realIP)
+			}
+			if got := h.ClientIP(r); got != tt.want {
+				t.Errorf("ClientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotifyAllowlist(t *testing.T) {
+	var hooked []string
+	h, client := newTestHandlers(t, "",
+		WithNotifyAllowlist("203.0.113.0/24"),
+		WithTrustedProxies("10.0.0.1"),
+		WithNotifyRejectHook(func(ip string, r *http.Request) {
+			hooked = append(hooked, ip)
+		}),
+	)
+
+	calls := 0
+	notify := h.NotifyFunc(func(ctx context.Context, req *NotifyRequest) error {
+		calls++
+		return nil
+	})
+	query := "/notify?" + signedNotify(client, "T1", "A", "1.00").Encode()
+
+	tests := []struct {
+		name       string
+		remote     string
+		xff        string
+		wantStatus int
+		wantBody   string
+	}{
+		{"allowed direct", "203.0.113.5:1234", "", http.StatusOK, "success"},
+		{"allowed via trusted proxy", "10.0.0.1:1234", "203.0.113.6", http.StatusOK, "success"},
+		{"allowed ipv4-mapped", "[::ffff:203.0.113.7]:1234", "", http.StatusOK, "success"},
+		{"rejected direct", "198.51.100.1:1234", "", http.StatusForbidden, "Forbidden\n"},
+		{"spoofed xff from untrusted remote", "198.51.100.2:1234", "203.0.113.5", http.StatusForbidden, "Forbidden\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, query, nil)
+			r.RemoteAddr = tt.remote
+			if tt.xff != "" {
+				r.Header.Set("X-Forwarded-For", tt.xff)
+			}
+			rec := httptest.NewRecorder()
+			notify.ServeHTTP(rec, r)
+			if rec.Code != tt.wantStatus || rec.Body.String() != tt.wantBody {
+				t.Errorf("Notify = %d %q, want %d %q", rec.Code, rec.Body.String(), tt.wantStatus, tt.wantBody)
+			}
+		})
+	}
+
+	if calls != 3 {
+		t.Errorf("callback calls = %d, want 3", calls)
+	}
+	stats := h.NotifyRejections()
+	if stats.Total != 2 || stats.BySource["198.51.100.1"] != 1 || stats.BySource["198.51.100.2"] != 1 {
+		t.Errorf("NotifyRejections() = %+v", stats)
+	}
+	if len(hooked) != 2 || hooked[0] != "198.51.100.1" {
+		t.Errorf("reject hook calls = %v", hooked)
+	}
+}
+
+func TestRejectionCounter_Cap(t *testing.T) {
+	var c rejectionCounter
+	for i := 0; i < maxRejectedSources+10; i++ {
+		c.add(fmt.Sprintf("198.51.%d.%d", i/256, i%256))
+	}
+	// 已记录的来源继续单独计数
+	c.add("198.51.0.0")
+
+	stats := c.snapshot()
+	if stats.Total != maxRejectedSources+11 {
+		t.Errorf("Total = %d, want %d", stats.Total, maxRejectedSources+11)
+	}
+	if len(stats.BySource) != maxRejectedSources+1 {
+		t.Errorf("len(BySource) = %d, want %d", len(stats.BySource), maxRejectedSources+1)
+	}
+	if stats.BySource["other"] != 10 || stats.BySource["198.51.0.0"] != 2 {
+		t.Errorf("other = %d, first = %d, want 10, 2", stats.BySource["other"], stats.BySource["198.51.0.0"])
+	}
+
+	// 快照与计数器互不影响
+	stats.BySource["other"] = 0
+	if c.snapshot().BySource["other"] != 10 {
+		t.Error("snapshot shares map with counter")
+	}
+}
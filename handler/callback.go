@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	epay "github.com/liuscraft/epay-sdk-go"
+)
+
+// 回调来源
+const (
+	NotifySourceHTTP        = "http"        // EPay 同步回调
+	NotifySourceInbox       = "inbox"       // 收件箱异步处理
+	NotifySourceCompensator = "compensator" // 回调丢失补偿
+	NotifySourceReplay      = "replay"      // 死信人工重放
+)
+
+// NotifyRequest 回调处理请求
+type NotifyRequest struct {
+	Notify     *epay.NotifyData  // 验签后的回调数据
+	Params     map[string]string // 原始回调参数
+	Source     string            // 回调来源（NotifySource*）
+	Request    *http.Request     // 原始 HTTP 请求，仅 Source 为 http 时非 nil
+	RemoteAddr string            // 回调来源 IP（经可信代理解析，异步处理时为入队时的地址）
+	ReceivedAt time.Time         // 接收时间
+	Attempt    int               // 第几次处理（从 1 开始，仅收件箱模式大于 1）
+}
+
+// NotifyHandlerFunc 带 context 和请求元数据的回调处理函数
+// 返回 error 时向 EPay 返回 "fail"（或由收件箱重试）；
+// 返回 Permanent(err) 表示业务上永久拒绝，向 EPay 返回 "success" 并记录失败
+type NotifyHandlerFunc func(ctx context.Context, req *NotifyRequest) error
+
+// WrapCallback 将 NotifyCallback 转换为 NotifyHandlerFunc
+func WrapCallback(callback NotifyCallback) NotifyHandlerFunc {
+	if callback == nil {
+		return nil
+	}
+	return func(ctx context.Context, req *NotifyRequest) error {
+		return callback(req.Notify)
+	}
+}
+
+// PermanentError 永久性业务错误，重试无法成功
+type PermanentError struct {
+	Err error
+}
+
+// Error 实现 error 接口
+func (e *PermanentError) Error() string {
+	return "permanent: " + e.Err.Error()
+}
+
+// Unwrap 返回原始错误
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// Permanent 将错误标记为永久性错误
+// 回调处理函数返回该错误时，Handler 向 EPay 返回 "success" 停止重试，
+// 同时记录日志并写入死信存储（如已配置）。该回调不会记为已处理，
+// 死信重放或之后的重复回调会再次执行回调处理函数，成功后死信自动移除；
+// 重放时再次返回永久性错误会作为 error 返回给调用方
+//
+//	if order.Cancelled {
+//	    return handler.Permanent(fmt.Errorf("order %s already cancelled", order.ID))
+//	}
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &PermanentError{Err: err}
+}
+
+// IsPermanent 判断错误是否为永久性错误
+func IsPermanent(err error) bool {
+	var permanent *PermanentError
+	return errors.As(err, &permanent)
+}
@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	epay "github.com/liuscraft/epay-sdk-go"
+	"github.com/liuscraft/epay-sdk-go/inbox"
+)
+
+func TestPermanent(t *testing.T) {
+	if Permanent(nil) != nil {
+		t.Error("Permanent(nil) should be nil")
+	}
+
+	cause := errors.New("order cancelled")
+	err := Permanent(cause)
+	if !IsPermanent(err) || !errors.Is(err, cause) {
+		t.Errorf("Permanent(cause) = %v, want permanent wrapping cause", err)
+	}
+	if err.Error() != "permanent: order cancelled" {
+		t.Errorf("Error() = %q", err.Error())
+	}
+
+	// 被再次包装后仍可识别
+	if !IsPermanent(fmt.Errorf("deliver: %w", err)) {
+		t.Error("IsPermanent(wrapped) = false, want true")
+	}
+	if IsPermanent(cause) || IsPermanent(nil) {
+		t.Error("IsPermanent(plain error) = true, want false")
+	}
+}
+
+func TestWrapCallback(t *testing.T) {
+	if WrapCallback(nil) != nil {
+		t.Error("WrapCallback(nil) should be nil")
+	}
+
+	boom := errors.New("boom")
+	var got *epay.NotifyData
+	fn := WrapCallback(func(data *epay.NotifyData) error {
+		got = data
+		return boom
+	})
+
+	data := &epay.NotifyData{TradeNo: "T1"}
+	if err := fn(context.Background(), &NotifyRequest{Notify: data}); err != boom {
+		t.Errorf("wrapped callback error = %v, want boom", err)
+	}
+	if got != data {
+		t.Errorf("wrapped callback data = %+v, want %+v", got, data)
+	}
+}
+
+func TestNotifyFunc_Request(t *testing.T) {
+	h, client := newTestHandlers(t, "")
+
+	var got *NotifyRequest
+	notify := h.NotifyFunc(func(ctx context.Context, req *NotifyRequest) error {
+		got = req
+		return nil
+	})
+
+	if rec := sendNotify(notify, signedNotify(client, "T1", "A", "1.00")); rec.Body.String() != "success" {
+		t.Fatalf("NotifyFunc = %q, want success", rec.Body.String())
+	}
+	if got == nil {
+		t.Fatal("callback not called")
+	}
+	if got.Source != NotifySourceHTTP || got.Request == nil || got.Request.Method != http.MethodGet {
+		t.Errorf("Source = %q, Request = %v", got.Source, got.Request)
+	}
+	if got.RemoteAddr != "192.0.2.1" || got.Attempt != 1 || got.ReceivedAt.IsZero() {
+		t.Errorf("RemoteAddr = %q, Attempt = %d, ReceivedAt = %v", got.RemoteAddr, got.Attempt, got.ReceivedAt)
+	}
+	if got.Notify.TradeNo != "T1" || got.Params["trade_no"] != "T1" || got.Params["sign"] == "" {
+		t.Errorf("Notify = %+v, Params = %v", got.Notify, got.Params)
+	}
+}
+
+func TestNotifyFunc_Permanent(t *testing.T) {
+	ctx := context.Background()
+	deadLetters := inbox.NewMemoryDeadLetterStore()
+	idempotency := NewMemoryIdempotencyStore()
+	h, client := newTestHandlers(t, "", WithIdempotencyStore(idempotency), WithDeadLetterStore(deadLetters))
+
+	calls := 0
+	rejected := true
+	fn := func(ctx context.Context, req *NotifyRequest) error {
+		calls++
+		if rejected {
+			return Permanent(errors.New("order cancelled"))
+		}
+		return nil
+	}
+
+	// 永久性错误向 EPay 返回 success，并写入死信，但不记为已处理
+	values := signedNotify(client, "T1", "A", "1.00")
+	if rec := sendNotify(h.NotifyFunc(fn), values); rec.Body.String() != "success" {
+		t.Fatalf("NotifyFunc = %q, want success", rec.Body.String())
+	}
+	id := inbox.DeadLetterID(map[string]string{"trade_no": "T1", "trade_status": epay.TradeStatusSuccess})
+	letter, err := h.DeadLetter(ctx, id)
+	if err != nil || letter.Attempts != 1 {
+		t.Fatalf("DeadLetter() = %+v, %v, want 1 attempt", letter, err)
+	}
+	if processed, _ := idempotency.IsProcessed(ctx, "T1"); processed {
+		t.Error("permanently rejected notify marked processed")
+	}
+
+	// 修复后重放：重新执行回调，成功后移除死信
+	rejected = false
+	if err := h.ReplayDeadLetter(ctx, id, fn); err != nil {
+		t.Fatalf("ReplayDeadLetter() error = %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("callback calls = %d, want 2", calls)
+	}
+	if _, err := h.DeadLetter(ctx, id); !errors.Is(err, inbox.ErrMessageNotFound) {
+		t.Errorf("DeadLetter() after replay error = %v, want ErrMessageNotFound", err)
+	}
+	if processed, _ := idempotency.IsProcessed(ctx, "T1"); !processed {
+		t.Error("replayed notify not marked processed")
+	}
+}
+
+func TestNotifyFunc_DuplicateResolvesDeadLetter(t *testing.T) {
+	ctx := context.Background()
+	deadLetters := inbox.NewMemoryDeadLetterStore()
+	idempotency := NewMemoryIdempotencyStore()
+	h, client := newTestHandlers(t, "", WithIdempotencyStore(idempotency), WithDeadLetterStore(deadLetters))
+
+	// 此前处理失败留下死信，之后已由其他实例处理成功（死信未能移除）
+	params := notifyParams("T1", "A", "1.00")
+	letter, err := deadLetters.Record(ctx, params, errors.New("db down"))
+	if err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	idempotency.MarkProcessed(ctx, "T1")
+
+	called := false
+	notify := h.NotifyFunc(func(ctx context.Context, req *NotifyRequest) error {
+		called = true
+		return nil
+	})
+	if rec := sendNotify(notify, signParams(client, params)); rec.Body.String() != "success" {
+		t.Fatalf("NotifyFunc = %q, want success", rec.Body.String())
+	}
+	if called {
+		t.Error("callback called for duplicate notify")
+	}
+	if _, err := h.DeadLetter(ctx, letter.ID); !errors.Is(err, inbox.ErrMessageNotFound) {
+		t.Errorf("DeadLetter() after duplicate error = %v, want ErrMessageNotFound", err)
+	}
+}
+
+func TestReplayDeadLetter_PermanentFails(t *testing.T) {
+	ctx := context.Background()
+	h, client := newTestHandlers(t, "",
+		WithIdempotencyStore(NewMemoryIdempotencyStore()),
+		WithDeadLetterStore(inbox.NewMemoryDeadLetterStore()),
+	)
+	fn := func(ctx context.Context, req *NotifyRequest) error {
+		return Permanent(errors.New("order cancelled"))
+	}
+
+	if rec := sendNotify(h.NotifyFunc(fn), signedNotify(client, "T1", "A", "1.00")); rec.Body.String() != "success" {
+		t.Fatalf("NotifyFunc = %q, want success", rec.Body.String())
+	}
+	id := inbox.DeadLetterID(map[string]string{"trade_no": "T1", "trade_status": epay.TradeStatusSuccess})
+
+	// 重放时再次被永久拒绝，返回错误并保留死信
+	if err := h.ReplayDeadLetter(ctx, id, fn); !IsPermanent(err) {
+		t.Errorf("ReplayDeadLetter() error = %v, want permanent error", err)
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/admin/dead-letters?id="+url.QueryEscape(id), nil)
+	rec := httptest.NewRecorder()
+	h.DeadLetterAdmin(fn).ServeHTTP(rec, r)
+	if rec.Code != http.StatusInternalServerError || !strings.Contains(rec.Body.String(), `"success":false`) {
+		t.Errorf("DeadLetterAdmin POST = %d %s, want 500 failure", rec.Code, rec.Body.String())
+	}
+
+	letter, err := h.DeadLetter(ctx, id)
+	if err != nil || letter.Attempts != 3 {
+		t.Errorf("DeadLetter() = %+v, %v, want 3 attempts", letter, err)
+	}
+}
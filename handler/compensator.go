@@ -0,0 +1,282 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	epay "github.com/liuscraft/epay-sdk-go"
+	"github.com/liuscraft/epay-sdk-go/tracker"
+)
+
+// 补偿器默认配置
+const (
+	defaultCompensateInterval = time.Minute
+	defaultCompensateAfter    = 5 * time.Minute
+	defaultCompensateMaxAge   = 24 * time.Hour
+	defaultCompensateBatch    = 100
+)
+
+// 补偿器创建错误
+var (
+	ErrCompensatorNoTracker     = errors.New("handler: compensator requires WithTracker or WithOrderStore")
+	ErrCompensatorNoIdempotency = errors.New("handler: compensator requires WithIdempotencyStore")
+)
+
+// Compensator 回调丢失补偿器
+// 定期查询本地仍为 created 且创建时间超过阈值的订单，网关显示已支付时合成与真实回调相同的
+// NotifyData，经过与 Notify 相同的处理流程（幂等检查、推进订单状态、业务回调）。
+// 真实回调与补偿共用幂等存储，业务回调只会执行一次；
+// 订单在业务回调成功后才推进到 paid，回调失败的订单会在下次扫描时重试。
+type Compensator struct {
+	h  *Handlers
+	fn NotifyHandlerFunc
+
+	interval time.Duration
+	after    time.Duration
+	maxAge   time.Duration
+	batch    int
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	stop      chan struct{}
+	done      chan struct{}
+	ctx       context.Context
+	cancel    context.CancelFunc
+}
+
+// CompensatorOption 补偿器配置选项
+type CompensatorOption func(*Compensator)
+
+// WithCompensateInterval 设置扫描间隔（默认 1 分钟）
+func WithCompensateInterval(d time.Duration) CompensatorOption {
+	return func(c *Compensator) {
+		if d > 0 {
+			c.interval = d
+		}
+	}
+}
+
+// WithCompensateAfter 设置订单创建多久后仍未收到回调才查询（默认 5 分钟）
+func WithCompensateAfter(d time.Duration) CompensatorOption {
+	return func(c *Compensator) {
+		if d > 0 {
+			c.after = d
+		}
+	}
+}
+
+// WithCompensateMaxAge 设置只补偿创建时间在该时长内的订单（默认 24 小时，<=0 表示不限制）
+// 无人关闭的未支付订单会一直保持 created，每次扫描都会逐个查询网关，不限制时扫描量随之增长
+func WithCompensateMaxAge(d time.Duration) CompensatorOption {
+	return func(c *Compensator) {
+		c.maxAge = d
+	}
+}
+
+// WithCompensateBatch 设置每次从订单存储分页读取的订单数（默认 100）
+func WithCompensateBatch(n int) CompensatorOption {
+	return func(c *Compensator) {
+		if n > 0 {
+			c.batch = n
+		}
+	}
+}
+
+// NewCompensator 创建回调丢失补偿器
+// 需要配置订单跟踪器（待补偿订单来源）和幂等存储（保证业务回调只执行一次）
+// 使用示例:
+//
+//	comp, err := handlers.NewCompensator(handler.WrapCallback(callback), handler.WithCompensateAfter(10*time.Minute))
+//	comp.Start()
+//	defer comp.Shutdown(ctx)
+func (h *Handlers) NewCompensator(fn NotifyHandlerFunc, opts ...CompensatorOption) (*Compensator, error) {
+	if h.tracker == nil {
+		return nil, ErrCompensatorNoTracker
+	}
+	if h.idempotency == nil {
+		return nil, ErrCompensatorNoIdempotency
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &Compensator{
+		h:        h,
+		fn:       fn,
+		interval: defaultCompensateInterval,
+		after:    defaultCompensateAfter,
+		maxAge:   defaultCompensateMaxAge,
+		batch:    defaultCompensateBatch,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c, nil
+}
+
+// Start 启动后台扫描
+func (c *Compensator) Start() {
+	c.startOnce.Do(func() {
+		go c.loop()
+	})
+}
+
+// Shutdown 停止后台扫描并等待当前扫描完成
+// ctx 到期时取消当前扫描并返回 ctx.Err()
+func (c *Compensator) Shutdown(ctx context.Context) error {
+	c.stopOnce.Do(func() {
+		close(c.stop)
+	})
+	// 未启动时直接标记完成
+	c.startOnce.Do(func() {
+		close(c.done)
+	})
+
+	select {
+	case <-c.done:
+		c.cancel()
+		return nil
+	case <-ctx.Done():
+		c.cancel()
+		return ctx.Err()
+	}
+}
+
+// RunOnce 执行一次扫描，返回补偿的订单数
+// 按创建时间分页遍历所有待补偿订单，避免最早的一批未支付订单长期占满结果而饿死后面的订单
+func (c *Compensator) RunOnce(ctx context.Context) (int, error) {
+	now := time.Now()
+	filter := tracker.ListFilter{
+		States:        []tracker.State{tracker.StateCreated},
+		CreatedBefore: now.Add(-c.after),
+		Limit:         c.batch,
+	}
+	if c.maxAge > 0 {
+		filter.CreatedAfter = now.Add(-c.maxAge)
+	}
+
+	compensated := 0
+	seen := make(map[string]bool)
+	for {
+		orders, err := c.h.tracker.Store().List(ctx, filter)
+		if err != nil {
+			return compensated, err
+		}
+
+		fresh := 0
+		for _, order := range orders {
+			if err := ctx.Err(); err != nil {
+				return compensated, err
+			}
+			// CreatedAfter 包含边界，上一页末尾同一时间的订单会再次出现
+			if seen[order.OutTradeNo] {
+				continue
+			}
+			seen[order.OutTradeNo] = true
+			fresh++
+
+			ok, err := c.compensate(ctx, order)
+			if err != nil {
+				c.h.logger.Printf("Compensate order %s failed: %v", order.OutTradeNo, err)
+				continue
+			}
+			if ok {
+				compensated++
+			}
+		}
+		if len(orders) < c.batch {
+			return compensated, nil
+		}
+
+		// 以本页最后一个订单的创建时间作为下一页的游标；
+		// 整页都已处理过说明同一时间的订单超过一页，跳过该时间点继续
+		cursor := orders[len(orders)-1].CreatedAt
+		if fresh == 0 {
+			cursor = cursor.Add(time.Nanosecond)
+		}
+		filter.CreatedAfter = cursor
+	}
+}
+
+// loop 后台扫描循环
+func (c *Compensator) loop() {
+	defer close(c.done)
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := c.RunOnce(c.ctx); err != nil {
+			c.h.logger.Printf("Compensate scan failed: %v", err)
+		} else if n > 0 {
+			c.h.logger.Printf("Compensated %d paid orders with lost notify", n)
+		}
+
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// compensate 查询单个订单，已支付时合成回调并处理
+func (c *Compensator) compensate(ctx context.Context, order *tracker.Order) (bool, error) {
+	detail, err := c.h.client.QueryOrderContext(ctx, &epay.OrderQueryRequest{OutTradeNo: order.OutTradeNo})
+	if err != nil {
+		return false, err
+	}
+	if !epay.IsOrderPaid(detail) {
+		return false, nil
+	}
+
+	// 合成签名的回调参数，与真实回调走相同的验签、预期订单校验与处理流程（死信重放同样可用）
+	params := c.notifyParams(detail)
+	notifyData, err := c.h.verifyNotify(ctx, params)
+	if err != nil {
+		return false, err
+	}
+
+	c.h.logger.Printf("Order %s paid but notify not received (trade_no=%s), compensating",
+		detail.OutTradeNo, detail.TradeNo)
+	req := &NotifyRequest{
+		Notify:     notifyData,
+		Params:     params,
+		Source:     NotifySourceCompensator,
+		ReceivedAt: time.Now(),
+		Attempt:    1,
+	}
+	if err := c.h.processNotify(ctx, req, c.fn); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// notifyParams 根据订单查询结果构建已签名的回调参数
+func (c *Compensator) notifyParams(detail *epay.OrderDetail) map[string]string {
+	pid := detail.PID
+	if pid == 0 {
+		pid = c.h.client.GetConfig().PID
+	}
+
+	params := map[string]string{
+		"pid":          strconv.Itoa(pid),
+		"trade_no":     detail.TradeNo,
+		"out_trade_no": detail.OutTradeNo,
+		"type":         detail.Type,
+		"name":         detail.Name,
+		"money":        detail.Money,
+		"trade_status": epay.TradeStatusSuccess,
+		"param":        detail.Param,
+		"sign_type":    "MD5",
+	}
+	params["sign"] = c.h.client.Sign(params)
+	return params
+}
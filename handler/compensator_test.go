@@ -0,0 +1,210 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	epay "github.com/liuscraft/epay-sdk-go"
+	"github.com/liuscraft/epay-sdk-go/tracker"
+)
+
+// newCompensatorTest 创建指向模拟网关的处理器，网关按 paid 返回订单支付状态
+// 订单 PAID、UNPAID 已在 1 小时前创建，本地状态均为 created
+func newCompensatorTest(t *testing.T, paid map[string]bool, opts ...Option) (*Handlers, *tracker.MemoryStore) {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		outTradeNo := r.URL.Query().Get("out_trade_no")
+		status := 0
+		if paid[outTradeNo] {
+			status = 1
+		}
+		fmt.Fprintf(w, `{"code":1,"trade_no":"T-%s","out_trade_no":"%s","type":"alipay","pid":1001,"name":"test","money":"1.00","status":%d}`,
+			outTradeNo, outTradeNo, status)
+	}))
+	t.Cleanup(server.Close)
+
+	store := tracker.NewMemoryStore()
+	created := time.Now().Add(-time.Hour)
+	for _, id := range []string{"PAID", "UNPAID"} {
+		order := &tracker.Order{OutTradeNo: id, Money: 100, State: tracker.StateCreated, CreatedAt: created}
+		if err := store.Put(context.Background(), order); err != nil {
+			t.Fatalf("Put(%s) error = %v", id, err)
+		}
+	}
+
+	opts = append([]Option{
+		WithOrderStore(store),
+		WithIdempotencyStore(NewMemoryIdempotencyStore()),
+	}, opts...)
+	h, _ := newTestHandlers(t, server.URL, opts...)
+	return h, store
+}
+
+// orderState 返回订单的本地状态
+func orderState(t *testing.T, store tracker.OrderStore, outTradeNo string) tracker.State {
+	t.Helper()
+	order, err := store.Get(context.Background(), outTradeNo)
+	if err != nil {
+		t.Fatalf("Get(%s) error = %v", outTradeNo, err)
+	}
+	return order.State
+}
+
+func TestCompensator_RunOnce(t *testing.T) {
+	ctx := context.Background()
+	h, store := newCompensatorTest(t, map[string]bool{"PAID": true})
+
+	var got []*NotifyRequest
+	comp, err := h.NewCompensator(func(ctx context.Context, req *NotifyRequest) error {
+		got = append(got, req)
+		return nil
+	}, WithCompensateAfter(time.Minute))
+	if err != nil {
+		t.Fatalf("NewCompensator() error = %v", err)
+	}
+
+	n, err := comp.RunOnce(ctx)
+	if err != nil || n != 1 {
+		t.Fatalf("RunOnce() = %d, %v, want 1", n, err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("callback calls = %d, want 1", len(got))
+	}
+	req := got[0]
+	if req.Source != NotifySourceCompensator || req.Notify.OutTradeNo != "PAID" || req.Notify.TradeNo != "T-PAID" ||
+		req.Notify.TradeStatus != epay.TradeStatusSuccess || req.Notify.Money != "1.00" {
+		t.Errorf("callback request = %+v, notify %+v", req, req.Notify)
+	}
+
+	if state := orderState(t, store, "PAID"); state != tracker.StatePaid {
+		t.Errorf("PAID state = %s, want paid", state)
+	}
+	if state := orderState(t, store, "UNPAID"); state != tracker.StateCreated {
+		t.Errorf("UNPAID state = %s, want created", state)
+	}
+
+	// 已补偿的订单不再处理
+	if n, err := comp.RunOnce(ctx); err != nil || n != 0 {
+		t.Errorf("second RunOnce() = %d, %v, want 0", n, err)
+	}
+	if len(got) != 1 {
+		t.Errorf("callback calls = %d, want 1", len(got))
+	}
+}
+
+func TestCompensator_RetryFailedCallback(t *testing.T) {
+	ctx := context.Background()
+	h, store := newCompensatorTest(t, map[string]bool{"PAID": true})
+
+	calls := 0
+	comp, err := h.NewCompensator(func(ctx context.Context, req *NotifyRequest) error {
+		calls++
+		if calls == 1 {
+			return errors.New("db down")
+		}
+		return nil
+	}, WithCompensateAfter(time.Minute))
+	if err != nil {
+		t.Fatalf("NewCompensator() error = %v", err)
+	}
+
+	// 回调失败时订单保持 created，下次扫描重试
+	if n, _ := comp.RunOnce(ctx); n != 0 {
+		t.Errorf("RunOnce() = %d, want 0", n)
+	}
+	if state := orderState(t, store, "PAID"); state != tracker.StateCreated {
+		t.Fatalf("PAID state after failed callback = %s, want created", state)
+	}
+
+	if n, err := comp.RunOnce(ctx); err != nil || n != 1 {
+		t.Errorf("RunOnce() retry = %d, %v, want 1", n, err)
+	}
+	if calls != 2 {
+		t.Errorf("callback calls = %d, want 2", calls)
+	}
+	if state := orderState(t, store, "PAID"); state != tracker.StatePaid {
+		t.Errorf("PAID state = %s, want paid", state)
+	}
+}
+
+func TestCompensator_ExpectedOrderMismatch(t *testing.T) {
+	ctx := context.Background()
+	resolver := func(ctx context.Context, outTradeNo string) (*epay.ExpectedOrder, error) {
+		// 本地订单金额与网关不一致
+		return &epay.ExpectedOrder{OutTradeNo: outTradeNo, Money: 200, Status: epay.OrderStatusUnpaid}, nil
+	}
+	h, store := newCompensatorTest(t, map[string]bool{"PAID": true}, WithExpectedOrderResolver(resolver))
+
+	called := false
+	comp, err := h.NewCompensator(func(ctx context.Context, req *NotifyRequest) error {
+		called = true
+		return nil
+	}, WithCompensateAfter(time.Minute))
+	if err != nil {
+		t.Fatalf("NewCompensator() error = %v", err)
+	}
+
+	if n, _ := comp.RunOnce(ctx); n != 0 {
+		t.Errorf("RunOnce() = %d, want 0", n)
+	}
+	if called {
+		t.Error("callback called for notify rejected by expected order check")
+	}
+	if state := orderState(t, store, "PAID"); state != tracker.StateCreated {
+		t.Errorf("PAID state = %s, want created", state)
+	}
+}
+
+func TestNewCompensator_Requirements(t *testing.T) {
+	h, _ := newTestHandlers(t, "", WithIdempotencyStore(NewMemoryIdempotencyStore()))
+	if _, err := h.NewCompensator(nil); !errors.Is(err, ErrCompensatorNoTracker) {
+		t.Errorf("NewCompensator() without tracker error = %v", err)
+	}
+
+	h, _ = newTestHandlers(t, "", WithOrderStore(tracker.NewMemoryStore()))
+	if _, err := h.NewCompensator(nil); !errors.Is(err, ErrCompensatorNoIdempotency) {
+		t.Errorf("NewCompensator() without idempotency error = %v", err)
+	}
+}
+
+func TestCompensator_PagesPastStaleOrders(t *testing.T) {
+	ctx := context.Background()
+	h, store := newCompensatorTest(t, map[string]bool{"PAID": true})
+
+	// 早于 PAID 创建、始终未支付的订单超过一页，其中一半创建时间相同
+	stale := time.Now().Add(-2 * time.Hour)
+	for i := 0; i < defaultCompensateBatch+50; i++ {
+		created := stale
+		if i%2 == 0 {
+			created = stale.Add(time.Duration(i) * time.Millisecond)
+		}
+		order := &tracker.Order{OutTradeNo: fmt.Sprintf("STALE-%03d", i), Money: 100, State: tracker.StateCreated, CreatedAt: created}
+		if err := store.Put(ctx, order); err != nil {
+			t.Fatalf("Put() error = %v", err)
+		}
+	}
+
+	calls := 0
+	comp, err := h.NewCompensator(func(ctx context.Context, req *NotifyRequest) error {
+		calls++
+		return nil
+	}, WithCompensateAfter(time.Minute))
+	if err != nil {
+		t.Fatalf("NewCompensator() error = %v", err)
+	}
+
+	if n, err := comp.RunOnce(ctx); err != nil || n != 1 {
+		t.Fatalf("RunOnce() = %d, %v, want 1", n, err)
+	}
+	if calls != 1 {
+		t.Errorf("callback calls = %d, want 1", calls)
+	}
+	if state := orderState(t, store, "PAID"); state != tracker.StatePaid {
+		t.Errorf("PAID state = %s, want paid", state)
+	}
+}
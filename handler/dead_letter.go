@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/liuscraft/epay-sdk-go/inbox"
+)
+
+// ErrDeadLetterDisabled 未配置死信存储
+var ErrDeadLetterDisabled = errors.New("handler: dead letter store not configured")
+
+// DeadLetters 列出处理失败的回调通知（按最近失败时间倒序）
+func (h *Handlers) DeadLetters(ctx context.Context) ([]*inbox.DeadLetter, error) {
+	if h.deadLetters == nil {
+		return nil, ErrDeadLetterDisabled
+	}
+	return h.deadLetters.List(ctx)
+}
+
+// DeadLetter 获取处理失败的回调通知
+func (h *Handlers) DeadLetter(ctx context.Context, id string) (*inbox.DeadLetter, error) {
+	if h.deadLetters == nil {
+		return nil, ErrDeadLetterDisabled
+	}
+	return h.deadLetters.Get(ctx, id)
+}
+
+// ReplayDeadLetter 重新验签并执行回调处理
+// 成功后从死信存储移除；失败时追加到错误历史并返回 error
+func (h *Handlers) ReplayDeadLetter(ctx context.Context, id string, fn NotifyHandlerFunc) error {
+	letter, err := h.DeadLetter(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	notifyData, err := h.client.VerifyNotify(letter.Params)
+	if err != nil {
+		h.recordDeadLetter(ctx, letter.Params, err)
+		return err
+	}
+
+	h.logger.Printf("Replaying dead letter %s (attempts=%d)", id, letter.Attempts)
+	return h.processNotify(ctx, &NotifyRequest{
+		Notify:     notifyData,
+		Params:     letter.Params,
+		Source:     NotifySourceReplay,
+		ReceivedAt: time.Now(),
+		Attempt:    letter.Attempts + 1,
+	}, fn)
+}
+
+// DeadLetterAdmin 返回死信管理 Handler
+// 该 Handler 可查看回调参数并触发业务回调，必须放在鉴权中间件之后，不能对外公开
+//
+//	GET    ?            列出死信
+//	GET    ?id=xxx      查看死信（含错误历史）
+//	POST   ?id=xxx      重放死信
+//	DELETE ?id=xxx      丢弃死信
+func (h *Handlers) DeadLetterAdmin(fn NotifyHandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.deadLetters == nil {
+			h.writeJSON(w, http.StatusNotFound, map[string]interface{}{
+				"success": false,
+				"message": "Dead letter store not configured",
+			})
+			return
+		}
+
+		id := r.URL.Query().Get("id")
+		if id == "" && r.Method != http.MethodGet {
+			h.writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+				"success": false,
+				"message": "id is required",
+			})
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			var (
+				data interface{}
+				err  error
+			)
+			if id == "" {
+				data, err = h.deadLetters.List(r.Context())
+			} else {
+				data, err = h.deadLetters.Get(r.Context(), id)
+			}
+			if err != nil {
+				h.writeDeadLetterError(w, err)
+				return
+			}
+			h.writeJSON(w, http.StatusOK, map[string]interface{}{
+				"success": true,
+				"data":    data,
+			})
+
+		case http.MethodPost:
+			if err := h.ReplayDeadLetter(r.Context(), id, fn); err != nil {
+				h.writeDeadLetterError(w, err)
+				return
+			}
+			h.writeJSON(w, http.StatusOK, map[string]interface{}{
+				"success": true,
+				"message": "Replayed",
+			})
+
+		case http.MethodDelete:
+			if err := h.deadLetters.Delete(r.Context(), id); err != nil {
+				h.writeDeadLetterError(w, err)
+				return
+			}
+			h.logger.Printf("Dead letter %s discarded", id)
+			h.writeJSON(w, http.StatusOK, map[string]interface{}{
+				"success": true,
+				"message": "Deleted",
+			})
+
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// recordDeadLetter 记录处理失败的回调
+func (h *Handlers) recordDeadLetter(ctx context.Context, params map[string]string, cause error) {
+	if h.deadLetters == nil {
+		return
+	}
+	letter, err := h.deadLetters.Record(ctx, params, cause)
+	if err != nil {
+		h.logger.Printf("Record dead letter for trade %s failed: %v", params["trade_no"], err)
+		return
+	}
+	h.logger.Printf("Notify for trade %s recorded as dead letter %s (attempts=%d)",
+		letter.TradeNo, letter.ID, letter.Attempts)
+}
+
+// resolveDeadLetter 回调处理成功后移除死信
+func (h *Handlers) resolveDeadLetter(ctx context.Context, params map[string]string) {
+	if h.deadLetters == nil {
+		return
+	}
+	if err := h.deadLetters.Delete(ctx, inbox.DeadLetterID(params)); err != nil {
+		h.logger.Printf("Remove dead letter for trade %s failed: %v", params["trade_no"], err)
+	}
+}
+
+// writeDeadLetterError 写入死信操作的错误响应
+func (h *Handlers) writeDeadLetterError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if errors.Is(err, inbox.ErrMessageNotFound) {
+		status = http.StatusNotFound
+	}
+	h.writeJSON(w, status, map[string]interface{}{
+		"success": false,
+		"message": err.Error(),
+	})
+}
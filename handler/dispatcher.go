@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	epay "github.com/liuscraft/epay-sdk-go"
+)
+
+// PaymentSucceededEvent 支付成功事件（TRADE_SUCCESS）
+type PaymentSucceededEvent struct {
+	OutTradeNo string           // 商户订单号
+	TradeNo    string           // EPay订单号
+	Type       string           // 支付方式
+	Name       string           // 商品名称
+	Money      epay.Money       // 支付金额
+	Param      string           // 业务扩展参数
+	Notify     *epay.NotifyData // 原始回调数据
+}
+
+// RefundEvent 退款事件（TRADE_REFUND）
+type RefundEvent struct {
+	OutTradeNo string           // 商户订单号
+	TradeNo    string           // EPay订单号
+	Money      epay.Money       // 回调中的金额
+	Notify     *epay.NotifyData // 原始回调数据
+}
+
+// UnknownStatusEvent 未知状态事件
+type UnknownStatusEvent struct {
+	TradeStatus string           // 回调中的支付状态
+	Notify      *epay.NotifyData // 原始回调数据
+}
+
+// ErrNoEventHandler 回调状态没有注册对应的事件处理函数
+var ErrNoEventHandler = errors.New("handler: no event handler registered")
+
+// NotifyDispatcher 按支付状态分发回调的事件分发器
+// 处理函数返回 error 时会向 EPay 返回 "fail"；
+// 未注册对应事件的处理函数时返回 ErrNoEventHandler，回调不会被确认（EPay 重试并写入死信）
+//
+// 使用示例:
+//
+//	handlers.Dispatcher().
+//	    OnPaymentSucceeded(func(e *handler.PaymentSucceededEvent) error {
+//	        return deliverGoods(e.OutTradeNo, e.Money)
+//	    }).
+//	    OnRefund(func(e *handler.RefundEvent) error {
+//	        return revokeGoods(e.OutTradeNo)
+//	    })
+//	http.Handle("/notify", handlers.DispatchNotify())
+type NotifyDispatcher struct {
+	mu        sync.RWMutex
+	logger    Logger
+	onPaid    func(*PaymentSucceededEvent) error
+	onRefund  func(*RefundEvent) error
+	onUnknown func(*UnknownStatusEvent) error
+}
+
+// newNotifyDispatcher 创建事件分发器
+func newNotifyDispatcher(logger Logger) *NotifyDispatcher {
+	return &NotifyDispatcher{logger: logger}
+}
+
+// Dispatcher 返回回调事件分发器
+func (h *Handlers) Dispatcher() *NotifyDispatcher {
+	return h.dispatcher
+}
+
+// DispatchNotify 返回按事件分发的支付回调 Handler
+// 等同于 h.Notify(h.Dispatcher().Dispatch)
+func (h *Handlers) DispatchNotify() http.Handler {
+	return h.Notify(h.dispatcher.Dispatch)
+}
+
+// OnPaymentSucceeded 注册支付成功事件处理函数
+func (d *NotifyDispatcher) OnPaymentSucceeded(fn func(*PaymentSucceededEvent) error) *NotifyDispatcher {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.onPaid = fn
+	return d
+}
+
+// OnRefund 注册退款事件处理函数
+func (d *NotifyDispatcher) OnRefund(fn func(*RefundEvent) error) *NotifyDispatcher {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.onRefund = fn
+	return d
+}
+
+// OnUnknownStatus 注册未知状态的兜底处理函数
+// 未注册时未知状态返回 ErrNoEventHandler
+func (d *NotifyDispatcher) OnUnknownStatus(fn func(*UnknownStatusEvent) error) *NotifyDispatcher {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.onUnknown = fn
+	return d
+}
+
+// Dispatch 按支付状态分发回调，可作为 NotifyCallback 使用
+func (d *NotifyDispatcher) Dispatch(data *epay.NotifyData) error {
+	d.mu.RLock()
+	onPaid, onRefund, onUnknown := d.onPaid, d.onRefund, d.onUnknown
+	d.mu.RUnlock()
+
+	switch data.TradeStatus {
+	case epay.TradeStatusSuccess:
+		if onPaid == nil {
+			return d.noHandler(data)
+		}
+		money, err := epay.ParseAmount(data.Money)
+		if err != nil {
+			// 已验签的回调金额无法解析，重试也不会成功
+			return Permanent(fmt.Errorf("parse notify money %q: %w", data.Money, err))
+		}
+		return onPaid(&PaymentSucceededEvent{
+			OutTradeNo: data.OutTradeNo,
+			TradeNo:    data.TradeNo,
+			Type:       data.Type,
+			Name:       data.Name,
+			Money:      money,
+			Param:      data.Param,
+			Notify:     data,
+		})
+
+	case epay.TradeStatusRefund:
+		if onRefund == nil {
+			return d.noHandler(data)
+		}
+		money, err := epay.ParseAmount(data.Money)
+		if err != nil {
+			// 已验签的回调金额无法解析，重试也不会成功
+			return Permanent(fmt.Errorf("parse notify money %q: %w", data.Money, err))
+		}
+		return onRefund(&RefundEvent{
+			OutTradeNo: data.OutTradeNo,
+			TradeNo:    data.TradeNo,
+			Money:      money,
+			Notify:     data,
+		})
+
+	default:
+		d.logger.Printf("Notify for order %s has unknown trade status %q", data.OutTradeNo, data.TradeStatus)
+		if onUnknown == nil {
+			return d.noHandler(data)
+		}
+		return onUnknown(&UnknownStatusEvent{
+			TradeStatus: data.TradeStatus,
+			Notify:      data,
+		})
+	}
+}
+
+// noHandler 记录并返回未注册事件处理函数的错误
+func (d *NotifyDispatcher) noHandler(data *epay.NotifyData) error {
+	d.logger.Printf("No handler for %s notify of order %s", data.TradeStatus, data.OutTradeNo)
+	return fmt.Errorf("%w: %s", ErrNoEventHandler, data.TradeStatus)
+}
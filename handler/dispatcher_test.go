@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"log"
+	"testing"
+
+	epay "github.com/liuscraft/epay-sdk-go"
+)
+
+func TestNotifyDispatcher_Dispatch(t *testing.T) {
+	var (
+		paid    *PaymentSucceededEvent
+		refund  *RefundEvent
+		unknown *UnknownStatusEvent
+	)
+	d := newNotifyDispatcher(log.New(io.Discard, "", 0)).
+		OnPaymentSucceeded(func(e *PaymentSucceededEvent) error {
+			paid = e
+			return nil
+		}).
+		OnRefund(func(e *RefundEvent) error {
+			refund = e
+			return nil
+		}).
+		OnUnknownStatus(func(e *UnknownStatusEvent) error {
+			unknown = e
+			return nil
+		})
+
+	err := d.Dispatch(&epay.NotifyData{TradeNo: "T1", OutTradeNo: "A", Money: "1.50", Param: "p", TradeStatus: epay.TradeStatusSuccess})
+	if err != nil || paid == nil || paid.OutTradeNo != "A" || paid.Money != 150 || paid.Param != "p" {
+		t.Errorf("Dispatch(TRADE_SUCCESS) = %v, event %+v", err, paid)
+	}
+
+	err = d.Dispatch(&epay.NotifyData{TradeNo: "T1", OutTradeNo: "A", Money: "0.50", TradeStatus: epay.TradeStatusRefund})
+	if err != nil || refund == nil || refund.TradeNo != "T1" || refund.Money != 50 {
+		t.Errorf("Dispatch(TRADE_REFUND) = %v, event %+v", err, refund)
+	}
+
+	err = d.Dispatch(&epay.NotifyData{OutTradeNo: "A", TradeStatus: "WAIT_BUYER_PAY"})
+	if err != nil || unknown == nil || unknown.TradeStatus != "WAIT_BUYER_PAY" {
+		t.Errorf("Dispatch(unknown) = %v, event %+v", err, unknown)
+	}
+
+	// 金额无法解析时返回永久性错误（写入死信，不再重试）
+	for _, status := range []string{epay.TradeStatusSuccess, epay.TradeStatusRefund} {
+		if err := d.Dispatch(&epay.NotifyData{Money: "abc", TradeStatus: status}); !IsPermanent(err) {
+			t.Errorf("Dispatch(%s) with invalid money error = %v, want permanent", status, err)
+		}
+	}
+}
+
+func TestNotifyDispatcher_NoHandler(t *testing.T) {
+	d := newNotifyDispatcher(log.New(io.Discard, "", 0))
+
+	for _, status := range []string{epay.TradeStatusSuccess, epay.TradeStatusRefund, "UNKNOWN"} {
+		err := d.Dispatch(&epay.NotifyData{Money: "1.00", TradeStatus: status})
+		if !errors.Is(err, ErrNoEventHandler) || IsPermanent(err) {
+			t.Errorf("Dispatch(%s) without handler error = %v, want retryable ErrNoEventHandler", status, err)
+		}
+	}
+}
+
+func TestDispatchNotify(t *testing.T) {
+	h, client := newTestHandlers(t, "", WithIdempotencyStore(NewMemoryIdempotencyStore()))
+
+	var paid, refunds int
+	h.Dispatcher().
+		OnPaymentSucceeded(func(e *PaymentSucceededEvent) error {
+			paid++
+			return nil
+		}).
+		OnRefund(func(e *RefundEvent) error {
+			refunds++
+			if refunds == 1 {
+				return errors.New("db down")
+			}
+			return nil
+		})
+	notify := h.DispatchNotify()
+
+	payment := signedNotify(client, "T1", "A", "1.00")
+	if rec := sendNotify(notify, payment); rec.Body.String() != "success" {
+		t.Fatalf("payment Notify = %q, want success", rec.Body.String())
+	}
+
+	// 同一 trade_no 的退款通知使用独立的幂等 key，不会被支付通知去重
+	params := notifyParams("T1", "A", "1.00")
+	params["trade_status"] = epay.TradeStatusRefund
+	refund := signParams(client, params)
+	if rec := sendNotify(notify, refund); rec.Body.String() != "fail" {
+		t.Fatalf("refund Notify = %q, want fail", rec.Body.String())
+	}
+	if rec := sendNotify(notify, refund); rec.Body.String() != "success" {
+		t.Fatalf("retried refund Notify = %q, want success", rec.Body.String())
+	}
+	sendNotify(notify, refund)
+	sendNotify(notify, payment)
+
+	if paid != 1 || refunds != 2 {
+		t.Errorf("paid = %d, refunds = %d, want 1, 2", paid, refunds)
+	}
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	tests := []struct {
+		status string
+		want   string
+	}{
+		{epay.TradeStatusSuccess, "T1"},
+		{epay.TradeStatusRefund, "T1/TRADE_REFUND"},
+		{"OTHER", "T1/OTHER"},
+	}
+	for _, tt := range tests {
+		if got := idempotencyKey(&epay.NotifyData{TradeNo: "T1", TradeStatus: tt.status}); got != tt.want {
+			t.Errorf("idempotencyKey(%s) = %q, want %q", tt.status, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/liuscraft/epay-sdk-go/forward"
+)
+
+func TestNotify_Forward(t *testing.T) {
+	secret := []byte("internal-secret")
+
+	var (
+		mu     sync.Mutex
+		events []*forward.Event
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		event, err := forward.ParseRequest(r, secret, time.Minute)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		mu.Lock()
+		events = append(events, event)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	fwd := forward.New(secret, []string{srv.URL})
+	h, client := newTestHandlers(t, "",
+		WithForwarder(fwd),
+		WithIdempotencyStore(NewMemoryIdempotencyStore()),
+	)
+
+	calls := 0
+	notify := h.NotifyFunc(func(ctx context.Context, req *NotifyRequest) error {
+		calls++
+		switch {
+		case req.Notify.OutTradeNo == "REJECTED":
+			return Permanent(errors.New("order cancelled"))
+		case calls == 1:
+			return errors.New("db down")
+		}
+		return nil
+	})
+
+	// 业务回调失败时不转发，EPay 重试成功后转发一次，之后的重复回调不再转发
+	paid := signedNotify(client, "T1", "A", "1.00")
+	if rec := sendNotify(notify, paid); rec.Body.String() != "fail" {
+		t.Fatalf("first Notify = %q, want fail", rec.Body.String())
+	}
+	for i := 0; i < 2; i++ {
+		if rec := sendNotify(notify, paid); rec.Body.String() != "success" {
+			t.Fatalf("retried Notify = %q, want success", rec.Body.String())
+		}
+	}
+
+	// 永久性错误不转发
+	if rec := sendNotify(notify, signedNotify(client, "T2", "REJECTED", "1.00")); rec.Body.String() != "success" {
+		t.Fatalf("rejected Notify = %q, want success", rec.Body.String())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := fwd.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != 1 {
+		t.Fatalf("forwarded %d events, want 1", len(events))
+	}
+	if events[0].OutTradeNo != "A" || events[0].TradeNo != "T1" {
+		t.Errorf("forwarded event = %+v", events[0])
+	}
+}
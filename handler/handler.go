@@ -3,14 +3,20 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"net/netip"
 	"strconv"
 	"time"
 
 	epay "github.com/liuscraft/epay-sdk-go"
+	"github.com/liuscraft/epay-sdk-go/forward"
+	"github.com/liuscraft/epay-sdk-go/inbox"
+	"github.com/liuscraft/epay-sdk-go/tracker"
 )
 
 // NotifyCallback 支付回调处理函数
@@ -24,6 +30,21 @@ type Handlers struct {
 	notifyURL string
 	returnURL string
 	logger    Logger
+	tracker   *tracker.Tracker
+
+	idempotency IdempotencyStore
+	notifyLocks keyedMutex
+	resolver    epay.ExpectedOrderResolver
+	dispatcher  *NotifyDispatcher
+	deadLetters inbox.DeadLetterStore
+
+	allowlist      []netip.Prefix
+	trustedProxies []netip.Prefix
+	rejectHook     func(ip string, r *http.Request)
+	rejections     rejectionCounter
+
+	ack       NotifyAck
+	forwarder *forward.Forwarder
 }
 
 // Logger 日志接口
@@ -55,6 +76,55 @@ func WithLogger(logger Logger) Option {
 	}
 }
 
+// WithTracker 设置订单跟踪器
+// 设置后 FormPayment/QRCodePayment 会记录创建的订单，Notify 会推进订单状态
+func WithTracker(t *tracker.Tracker) Option {
+	return func(h *Handlers) {
+		h.tracker = t
+	}
+}
+
+// WithOrderStore 使用指定的订单存储跟踪订单（等同于 WithTracker(tracker.New(tracker.WithStore(store))))
+func WithOrderStore(store tracker.OrderStore) Option {
+	return func(h *Handlers) {
+		h.tracker = tracker.New(tracker.WithStore(store))
+	}
+}
+
+// WithIdempotencyStore 启用回调幂等处理
+// 同一 trade_no 的回调成功处理后会被记录，重复回调跳过业务回调直接返回 "success"；
+// 并发到达的重复回调会被串行化处理
+func WithIdempotencyStore(store IdempotencyStore) Option {
+	return func(h *Handlers) {
+		h.idempotency = store
+	}
+}
+
+// WithExpectedOrderResolver 设置预期订单加载函数
+// 设置后 Notify 会校验回调的 pid、订单号、订单状态和金额，不匹配时记录安全告警并返回 "fail"
+func WithExpectedOrderResolver(resolver epay.ExpectedOrderResolver) Option {
+	return func(h *Handlers) {
+		h.resolver = resolver
+	}
+}
+
+// WithDeadLetterStore 设置死信存储
+// 业务回调返回 error 的回调通知会连同错误历史记录到死信存储，处理成功后自动移除
+func WithDeadLetterStore(store inbox.DeadLetterStore) Option {
+	return func(h *Handlers) {
+		h.deadLetters = store
+	}
+}
+
+// WithForwarder 设置回调转发器
+// 业务回调成功后，回调会由转发器在后台签名转发给内部服务，不影响回调应答；
+// 回调失败或返回永久性错误时不转发，重试成功后再转发
+func WithForwarder(f *forward.Forwarder) Option {
+	return func(h *Handlers) {
+		h.forwarder = f
+	}
+}
+
 // NewHandlers 创建 HTTP 处理器集合
 // 使用示例:
 //
@@ -74,6 +144,8 @@ func NewHandlers(client *epay.Client, opts ...Option) *Handlers {
 		opt(h)
 	}
 
+	h.dispatcher = newNotifyDispatcher(h.logger)
+
 	return h
 }
 
@@ -101,14 +173,15 @@ func (h *Handlers) FormPayment() http.Handler {
 		outTradeNo := fmt.Sprintf("ORDER%d", time.Now().UnixNano())
 
 		// 构建表单
-		htmlForm, err := h.client.BuildFormPayment(&epay.FormPaymentRequest{
+		payReq := &epay.FormPaymentRequest{
 			Type:       payType,
 			OutTradeNo: outTradeNo,
 			NotifyURL:  h.notifyURL,
 			ReturnURL:  h.returnURL,
 			Name:       name,
 			Money:      money,
-		})
+		}
+		htmlForm, err := h.client.BuildFormPayment(payReq)
 
 		if err != nil {
 			h.logger.Printf("Build form payment failed: %v", err)
@@ -116,6 +189,15 @@ func (h *Handlers) FormPayment() http.Handler {
 			return
 		}
 
+		// 记录订单
+		if h.tracker != nil {
+			if _, err := h.tracker.TrackFormPayment(r.Context(), payReq); err != nil {
+				h.logger.Printf("Track order %s failed: %v", outTradeNo, err)
+				http.Error(w, "Failed to create payment", http.StatusInternalServerError)
+				return
+			}
+		}
+
 		w.Header().Set("Content-Type", "text/html; charset=utf-8")
 		w.Write([]byte(htmlForm))
 	})
@@ -168,7 +250,7 @@ func (h *Handlers) QRCodePayment() http.Handler {
 		}
 
 		// 创建支付
-		resp, err := h.client.CreatePayment(&epay.PaymentRequest{
+		payReq := &epay.PaymentRequest{
 			Type:       req.PayType,
 			OutTradeNo: outTradeNo,
 			NotifyURL:  h.notifyURL,
@@ -177,7 +259,8 @@ func (h *Handlers) QRCodePayment() http.Handler {
 			Money:      req.Money,
 			ClientIP:   clientIP,
 			Device:     "pc",
-		})
+		}
+		resp, err := h.client.CreatePayment(payReq)
 
 		if err != nil {
 			h.logger.Printf("Create payment failed: %v", err)
@@ -188,6 +271,18 @@ func (h *Handlers) QRCodePayment() http.Handler {
 			return
 		}
 
+		// 记录订单
+		if h.tracker != nil {
+			if _, err := h.tracker.TrackPayment(r.Context(), payReq, resp); err != nil {
+				h.logger.Printf("Track order %s failed: %v", outTradeNo, err)
+				h.writeJSON(w, http.StatusInternalServerError, map[string]interface{}{
+					"success": false,
+					"message": "Failed to create payment",
+				})
+				return
+			}
+		}
+
 		h.writeJSON(w, http.StatusOK, map[string]interface{}{
 			"success": true,
 			"data": map[string]interface{}{
@@ -204,39 +299,165 @@ func (h *Handlers) QRCodePayment() http.Handler {
 // Notify 返回支付回调 Handler
 // callback 函数用于处理业务逻辑，如果返回 error，会向 EPay 返回 "fail"
 func (h *Handlers) Notify(callback NotifyCallback) http.Handler {
+	return h.NotifyFunc(WrapCallback(callback))
+}
+
+// NotifyFunc 返回支付回调 Handler，回调处理函数可获取 context 和请求元数据
+// fn 返回 error 时向 EPay 返回 "fail"；返回 Permanent(err) 时返回 "success" 并记录失败
+func (h *Handlers) NotifyFunc(fn NotifyHandlerFunc) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 检查回调来源
+		if !h.allowNotifySource(w, r) {
+			return
+		}
+
 		// 解析回调参数
-		params := epay.ParseNotifyParams(r)
+		params, err := epay.ParseNotifyParams(r)
+		if err != nil {
+			h.logger.Printf("Parse notify params failed: %v", err)
+			h.writeAck(w, false)
+			return
+		}
 
 		h.logger.Printf("Received payment notify: %+v", params)
 
-		// 验证签名
-		notifyData, err := h.client.VerifyNotify(params)
+		// 验证回调
+		notifyData, err := h.verifyNotify(r.Context(), params)
 		if err != nil {
-			h.logger.Printf("Verify notify signature failed: %v", err)
-			w.Write([]byte("fail"))
+			h.writeAck(w, false)
 			return
 		}
 
-		// 执行业务回调
-		if callback != nil {
-			if err := callback(notifyData); err != nil {
-				h.logger.Printf("Notify callback failed: %v", err)
-				w.Write([]byte("fail"))
-				return
-			}
+		// 处理回调
+		req := &NotifyRequest{
+			Notify:     notifyData,
+			Params:     params,
+			Source:     NotifySourceHTTP,
+			Request:    r,
+			RemoteAddr: h.ClientIP(r),
+			ReceivedAt: time.Now(),
+			Attempt:    1,
+		}
+		if err := h.processNotify(r.Context(), req, fn); err != nil {
+			h.writeAck(w, false)
+			return
 		}
 
 		// 返回成功
-		w.Write([]byte("success"))
+		h.writeAck(w, true)
 	})
 }
 
+// verifyNotify 验证回调签名，并按配置校验预期订单
+// 校验失败时记录日志并返回 error
+func (h *Handlers) verifyNotify(ctx context.Context, params map[string]string) (*epay.NotifyData, error) {
+	// 验证签名
+	notifyData, err := h.client.VerifyNotify(params)
+	if err != nil {
+		h.logger.Printf("Verify notify signature failed: %v", err)
+		return nil, err
+	}
+
+	if notifyData.RefundRequired {
+		h.logger.Printf("Order %s was cancelled but paid (trade_no=%s), refund required",
+			notifyData.OutTradeNo, notifyData.TradeNo)
+	}
+
+	// 校验回调与预期订单是否一致
+	if h.resolver != nil {
+		if err := h.client.CheckNotifyOrder(ctx, notifyData, h.resolver); err != nil {
+			if errors.Is(err, epay.ErrNotifyMismatch) {
+				h.logger.Printf("SECURITY ALERT: signed notify rejected: %v (params: %+v)", err, params)
+			} else {
+				h.logger.Printf("Check notify for order %s failed: %v", notifyData.OutTradeNo, err)
+			}
+			return nil, err
+		}
+	}
+
+	return notifyData, nil
+}
+
+// processNotify 处理已验证的回调：幂等检查、执行业务回调、推进订单状态
+// 返回 error 表示需要 EPay（或收件箱）重试；永久性错误向 EPay 返回成功，但不记为已处理（死信重放时返回该错误）。
+// 订单状态在业务回调成功后才推进，回调失败时订单保持 created，补偿器会再次处理
+func (h *Handlers) processNotify(ctx context.Context, req *NotifyRequest, fn NotifyHandlerFunc) error {
+	notifyData := req.Notify
+
+	// 串行化同一订单的并发回调
+	if h.idempotency != nil {
+		unlock := h.notifyLocks.Lock(idempotencyKey(notifyData))
+		defer unlock()
+
+		processed, err := h.idempotency.IsProcessed(ctx, idempotencyKey(notifyData))
+		if err != nil {
+			h.logger.Printf("Check notify idempotency for trade %s failed: %v", notifyData.TradeNo, err)
+			return err
+		}
+		if processed {
+			h.logger.Printf("Duplicate notify for trade %s (%s), skipped", notifyData.TradeNo, notifyData.TradeStatus)
+			// 已成功处理，移除此前失败留下的死信，并补齐上次未能完成的订单状态推进
+			h.resolveDeadLetter(ctx, req.Params)
+			return h.applyNotify(ctx, notifyData)
+		}
+	}
+
+	// 执行业务回调
+	var permanent error
+	if fn != nil {
+		if err := fn(ctx, req); err != nil {
+			h.recordDeadLetter(ctx, req.Params, err)
+			// 人工重放时永久性错误同样返回给调用方，死信保留
+			if !IsPermanent(err) || req.Source == NotifySourceReplay {
+				h.logger.Printf("Notify callback failed: %v", err)
+				return err
+			}
+			h.logger.Printf("Notify callback for order %s rejected permanently, acknowledged: %v",
+				notifyData.OutTradeNo, err)
+			permanent = err
+		}
+	}
+	if permanent == nil {
+		h.resolveDeadLetter(ctx, req.Params)
+	}
+
+	// 记录已处理，失败时仍视为成功，避免重试导致业务回调重复执行
+	// 永久性错误不记录，保留死信以便修复后重放
+	if h.idempotency != nil && permanent == nil {
+		if err := h.idempotency.MarkProcessed(ctx, idempotencyKey(notifyData)); err != nil {
+			h.logger.Printf("Mark notify for trade %s processed failed: %v", notifyData.TradeNo, err)
+		}
+	}
+
+	// 业务回调成功后转发给内部服务
+	if h.forwarder != nil && permanent == nil {
+		if err := h.forwarder.ForwardAsync(notifyData); err != nil {
+			h.logger.Printf("Forward notify for order %s failed: %v", notifyData.OutTradeNo, err)
+		}
+	}
+
+	// 推进订单状态，失败时返回 error 由重试补齐（已记录处理的重复回调不会再执行业务回调）
+	if err := h.applyNotify(ctx, notifyData); err != nil {
+		return err
+	}
+
+	// 唤醒等待该订单支付的 WaitForPayment
+	if permanent == nil {
+		h.client.SignalPaid(notifyData)
+	}
+
+	return nil
+}
+
 // Return 返回支付同步跳转 Handler
 // 返回简单的成功页面，可以自定义
 func (h *Handlers) Return() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		params := epay.ParseNotifyParams(r)
+		params, err := epay.ParseNotifyParams(r)
+		if err != nil {
+			http.Error(w, "Invalid parameters", http.StatusBadRequest)
+			return
+		}
 
 		html := `<!DOCTYPE html>
 <html>
@@ -300,6 +521,29 @@ func (h *Handlers) QueryOrder() http.Handler {
 	})
 }
 
+// applyNotify 将回调应用到订单跟踪器，存储错误时返回 error（需要重试）
+// 非法状态迁移（如已关闭的订单收到支付通知）和未跟踪的订单只记录日志
+func (h *Handlers) applyNotify(ctx context.Context, notifyData *epay.NotifyData) error {
+	if h.tracker == nil {
+		return nil
+	}
+
+	_, err := h.tracker.ApplyNotify(ctx, notifyData)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, tracker.ErrIllegalTransition):
+		h.logger.Printf("Notify for order %s rejected by tracker: %v", notifyData.OutTradeNo, err)
+		return nil
+	case errors.Is(err, tracker.ErrOrderNotFound):
+		h.logger.Printf("Notify for untracked order %s", notifyData.OutTradeNo)
+		return nil
+	default:
+		h.logger.Printf("Apply notify for order %s failed: %v", notifyData.OutTradeNo, err)
+		return err
+	}
+}
+
 // writeJSON 写入 JSON 响应
 func (h *Handlers) writeJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
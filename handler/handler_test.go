@@ -0,0 +1,209 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	epay "github.com/liuscraft/epay-sdk-go"
+)
+
+// newTestHandlers 创建不输出日志的处理器，apiURL 为空时使用不可达的网关地址
+func newTestHandlers(t *testing.T, apiURL string, opts ...Option) (*Handlers, *epay.Client) {
+	t.Helper()
+	if apiURL == "" {
+		apiURL = "http://127.0.0.1:1"
+	}
+	client, err := epay.NewClient(&epay.Config{
+		PID:        1001,
+		Key:        "testkey123",
+		APIBaseURL: apiURL,
+	})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	opts = append([]Option{WithLogger(log.New(io.Discard, "", 0))}, opts...)
+	return NewHandlers(client, opts...), client
+}
+
+// notifyParams 构造支付成功回调参数（未签名）
+func notifyParams(tradeNo, outTradeNo, money string) map[string]string {
+	return map[string]string{
+		"pid":          "1001",
+		"trade_no":     tradeNo,
+		"out_trade_no": outTradeNo,
+		"type":         "alipay",
+		"name":         "test",
+		"money":        money,
+		"trade_status": epay.TradeStatusSuccess,
+	}
+}
+
+// signParams 对回调参数签名
+func signParams(client *epay.Client, params map[string]string) url.Values {
+	values := url.Values{}
+	for k, v := range params {
+		values.Set(k, v)
+	}
+	values.Set("sign", client.Sign(params))
+	values.Set("sign_type", epay.DefaultSignType)
+	return values
+}
+
+// signedNotify 构造已签名的支付成功回调参数
+func signedNotify(client *epay.Client, tradeNo, outTradeNo, money string) url.Values {
+	return signParams(client, notifyParams(tradeNo, outTradeNo, money))
+}
+
+// sendNotify 以 GET 请求调用回调 Handler
+func sendNotify(h http.Handler, values url.Values) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/notify?"+values.Encode(), nil)
+	req.RemoteAddr = "192.0.2.1:12345"
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestNotify(t *testing.T) {
+	h, client := newTestHandlers(t, "")
+
+	var got *epay.NotifyData
+	notify := h.Notify(func(data *epay.NotifyData) error {
+		got = data
+		return nil
+	})
+
+	rec := sendNotify(notify, signedNotify(client, "T1", "A", "1.00"))
+	if rec.Code != http.StatusOK || rec.Body.String() != "success" {
+		t.Fatalf("Notify = %d %q, want 200 success", rec.Code, rec.Body.String())
+	}
+	if got == nil || got.TradeNo != "T1" || got.OutTradeNo != "A" || got.Money != "1.00" {
+		t.Errorf("callback data = %+v", got)
+	}
+}
+
+func TestNotify_InvalidSign(t *testing.T) {
+	h, client := newTestHandlers(t, "")
+
+	called := false
+	notify := h.Notify(func(data *epay.NotifyData) error {
+		called = true
+		return nil
+	})
+
+	values := signedNotify(client, "T1", "A", "1.00")
+	values.Set("money", "100.00")
+	if rec := sendNotify(notify, values); rec.Body.String() != "fail" {
+		t.Errorf("Notify = %q, want fail", rec.Body.String())
+	}
+	if called {
+		t.Error("callback called for notify with invalid sign")
+	}
+}
+
+func TestNotify_CallbackError(t *testing.T) {
+	h, client := newTestHandlers(t, "", WithIdempotencyStore(NewMemoryIdempotencyStore()))
+
+	calls := 0
+	notify := h.Notify(func(data *epay.NotifyData) error {
+		calls++
+		if calls == 1 {
+			return errors.New("db down")
+		}
+		return nil
+	})
+
+	values := signedNotify(client, "T1", "A", "1.00")
+	if rec := sendNotify(notify, values); rec.Body.String() != "fail" {
+		t.Fatalf("first Notify = %q, want fail", rec.Body.String())
+	}
+	// 失败的回调未记录为已处理，EPay 重试时再次执行
+	if rec := sendNotify(notify, values); rec.Body.String() != "success" {
+		t.Fatalf("retried Notify = %q, want success", rec.Body.String())
+	}
+	if calls != 2 {
+		t.Errorf("callback calls = %d, want 2", calls)
+	}
+}
+
+func TestNotify_Duplicate(t *testing.T) {
+	store := NewMemoryIdempotencyStore()
+	h, client := newTestHandlers(t, "", WithIdempotencyStore(store))
+
+	calls := 0
+	notify := h.Notify(func(data *epay.NotifyData) error {
+		calls++
+		return nil
+	})
+
+	values := signedNotify(client, "T1", "A", "1.00")
+	for i := 0; i < 3; i++ {
+		if rec := sendNotify(notify, values); rec.Body.String() != "success" {
+			t.Fatalf("Notify #%d = %q, want success", i+1, rec.Body.String())
+		}
+	}
+	if calls != 1 {
+		t.Errorf("callback calls = %d, want 1", calls)
+	}
+	if processed, _ := store.IsProcessed(context.Background(), "T1"); !processed {
+		t.Error("trade T1 not marked processed")
+	}
+
+	// 其他订单不受影响
+	sendNotify(notify, signedNotify(client, "T2", "B", "1.00"))
+	if calls != 2 {
+		t.Errorf("callback calls = %d, want 2", calls)
+	}
+}
+
+func TestNotify_ConcurrentDuplicates(t *testing.T) {
+	h, client := newTestHandlers(t, "", WithIdempotencyStore(NewMemoryIdempotencyStore()))
+
+	var calls, active, maxActive int32
+	notify := h.Notify(func(data *epay.NotifyData) error {
+		atomic.AddInt32(&calls, 1)
+		n := atomic.AddInt32(&active, 1)
+		defer atomic.AddInt32(&active, -1)
+		for {
+			max := atomic.LoadInt32(&maxActive)
+			if n <= max || atomic.CompareAndSwapInt32(&maxActive, max, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	})
+
+	values := signedNotify(client, "T1", "A", "1.00")
+	var wg sync.WaitGroup
+	bodies := make([]string, 10)
+	for i := range bodies {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			bodies[i] = sendNotify(notify, values).Body.String()
+		}(i)
+	}
+	wg.Wait()
+
+	for i, body := range bodies {
+		if body != "success" {
+			t.Errorf("Notify #%d = %q, want success", i+1, body)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("callback calls = %d, want 1", calls)
+	}
+	if maxActive != 1 {
+		t.Errorf("max concurrent callbacks = %d, want 1", maxActive)
+	}
+}
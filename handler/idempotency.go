@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	epay "github.com/liuscraft/epay-sdk-go"
+)
+
+// IdempotencyStore 回调幂等记录存储
+// 按 trade_no 记录已成功处理的回调（非支付成功通知使用 trade_no/trade_status 作为 key），重复回调将跳过业务处理直接返回 "success"
+// 多实例部署时应使用共享存储（如数据库、Redis）实现
+type IdempotencyStore interface {
+	// IsProcessed 检查回调是否已成功处理
+	IsProcessed(ctx context.Context, tradeNo string) (bool, error)
+	// MarkProcessed 记录回调已成功处理
+	MarkProcessed(ctx context.Context, tradeNo string) error
+}
+
+// MemoryIdempotencyStore 内存幂等记录存储（重启后丢失）
+type MemoryIdempotencyStore struct {
+	mu        sync.RWMutex
+	processed map[string]time.Time
+}
+
+// NewMemoryIdempotencyStore 创建内存幂等记录存储
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{
+		processed: make(map[string]time.Time),
+	}
+}
+
+// IsProcessed 检查回调是否已成功处理
+func (s *MemoryIdempotencyStore) IsProcessed(ctx context.Context, tradeNo string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.processed[tradeNo]
+	return ok, nil
+}
+
+// MarkProcessed 记录回调已成功处理
+func (s *MemoryIdempotencyStore) MarkProcessed(ctx context.Context, tradeNo string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.processed[tradeNo]; !ok {
+		s.processed[tradeNo] = time.Now()
+	}
+	return nil
+}
+
+// idempotencyKey 返回回调的幂等 key
+// 支付成功通知使用 trade_no，其他状态（如退款）追加 trade_status，避免与支付通知互相去重
+func idempotencyKey(data *epay.NotifyData) string {
+	if data.TradeStatus == epay.TradeStatusSuccess {
+		return data.TradeNo
+	}
+	return data.TradeNo + "/" + data.TradeStatus
+}
+
+// keyedMutex 按 key 加锁，用于串行化同一 trade_no 的并发回调
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*keyedLock
+}
+
+// keyedLock 单个 key 的锁及其等待者计数
+type keyedLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// Lock 锁定 key，返回解锁函数
+func (m *keyedMutex) Lock(key string) (unlock func()) {
+	m.mu.Lock()
+	if m.locks == nil {
+		m.locks = make(map[string]*keyedLock)
+	}
+	l, ok := m.locks[key]
+	if !ok {
+		l = &keyedLock{}
+		m.locks[key] = l
+	}
+	l.refs++
+	m.mu.Unlock()
+
+	l.mu.Lock()
+	return func() {
+		l.mu.Unlock()
+
+		m.mu.Lock()
+		l.refs--
+		if l.refs == 0 {
+			delete(m.locks, key)
+		}
+		m.mu.Unlock()
+	}
+}
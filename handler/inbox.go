@@ -0,0 +1,292 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	epay "github.com/liuscraft/epay-sdk-go"
+	"github.com/liuscraft/epay-sdk-go/inbox"
+)
+
+// 收件箱默认配置
+const (
+	defaultInboxWorkers      = 4
+	defaultInboxMaxAttempts  = 10
+	defaultInboxBackoff      = time.Second
+	defaultInboxMaxBackoff   = 5 * time.Minute
+	defaultInboxPollInterval = time.Second
+)
+
+// Inbox 异步回调处理器（收件箱模式）
+// 回调验签通过后先持久化到队列并立即返回 "success"，再由后台 worker 执行业务回调；
+// 业务回调失败时按指数退避重试，超过最大次数后放弃并记录日志；
+// 配置 WithDeadLetterStore 时失败的回调保留在死信存储中，可人工重放。
+type Inbox struct {
+	h     *Handlers
+	queue inbox.Queue
+	fn    NotifyHandlerFunc
+
+	workers      int
+	maxAttempts  int
+	backoff      time.Duration
+	maxBackoff   time.Duration
+	pollInterval time.Duration
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	wake      chan struct{}
+	stop      chan struct{}
+	ctx       context.Context
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+}
+
+// InboxOption 收件箱配置选项
+type InboxOption func(*Inbox)
+
+// WithInboxWorkers 设置 worker 数量（默认 4）
+func WithInboxWorkers(n int) InboxOption {
+	return func(in *Inbox) {
+		if n > 0 {
+			in.workers = n
+		}
+	}
+}
+
+// WithInboxMaxAttempts 设置单条回调的最大处理次数（默认 10）
+func WithInboxMaxAttempts(n int) InboxOption {
+	return func(in *Inbox) {
+		if n > 0 {
+			in.maxAttempts = n
+		}
+	}
+}
+
+// WithInboxBackoff 设置重试退避的初始间隔和最大间隔（默认 1s、5min）
+func WithInboxBackoff(initial, max time.Duration) InboxOption {
+	return func(in *Inbox) {
+		if initial > 0 {
+			in.backoff = initial
+		}
+		if max > 0 {
+			in.maxBackoff = max
+		}
+	}
+}
+
+// WithInboxPollInterval 设置队列为空时的轮询间隔（默认 1s）
+func WithInboxPollInterval(d time.Duration) InboxOption {
+	return func(in *Inbox) {
+		if d > 0 {
+			in.pollInterval = d
+		}
+	}
+}
+
+// NewInbox 创建收件箱模式的回调处理器
+// 使用示例:
+//
+//	queue, err := inbox.OpenFileQueue("/var/lib/myapp/inbox.log")
+//	in := handlers.NewInbox(queue, handler.WrapCallback(callback), handler.WithInboxWorkers(8))
+//	in.Start()
+//	defer in.Shutdown(ctx)
+//	http.Handle("/notify", in.Notify())
+func (h *Handlers) NewInbox(queue inbox.Queue, fn NotifyHandlerFunc, opts ...InboxOption) *Inbox {
+	ctx, cancel := context.WithCancel(context.Background())
+	in := &Inbox{
+		h:            h,
+		queue:        queue,
+		fn:           fn,
+		workers:      defaultInboxWorkers,
+		maxAttempts:  defaultInboxMaxAttempts,
+		backoff:      defaultInboxBackoff,
+		maxBackoff:   defaultInboxMaxBackoff,
+		pollInterval: defaultInboxPollInterval,
+		wake:         make(chan struct{}, 1),
+		stop:         make(chan struct{}),
+		ctx:          ctx,
+		cancel:       cancel,
+	}
+
+	for _, opt := range opts {
+		opt(in)
+	}
+
+	return in
+}
+
+// Notify 返回收件箱模式的支付回调 Handler
+// 验签通过并写入队列后返回 "success"，写入失败返回 "fail"
+func (in *Inbox) Notify() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 检查回调来源
+		if !in.h.allowNotifySource(w, r) {
+			return
+		}
+
+		// 解析回调参数
+		params, err := epay.ParseNotifyParams(r)
+		if err != nil {
+			in.h.logger.Printf("Parse notify params failed: %v", err)
+			in.h.writeAck(w, false)
+			return
+		}
+
+		in.h.logger.Printf("Received payment notify: %+v", params)
+
+		// 验证回调
+		notifyData, err := in.h.verifyNotify(r.Context(), params)
+		if err != nil {
+			in.h.writeAck(w, false)
+			return
+		}
+
+		// 持久化到队列
+		msg := inbox.NewMessage(params)
+		msg.RemoteAddr = in.h.ClientIP(r)
+		if err := in.queue.Enqueue(r.Context(), msg); err != nil {
+			in.h.logger.Printf("Enqueue notify for order %s failed: %v", notifyData.OutTradeNo, err)
+			in.h.writeAck(w, false)
+			return
+		}
+
+		// 唤醒 worker
+		select {
+		case in.wake <- struct{}{}:
+		default:
+		}
+
+		in.h.writeAck(w, true)
+	})
+}
+
+// Start 启动后台 worker
+func (in *Inbox) Start() {
+	in.startOnce.Do(func() {
+		for i := 0; i < in.workers; i++ {
+			in.wg.Add(1)
+			go in.work()
+		}
+	})
+}
+
+// Shutdown 停止取出新消息并等待处理中的回调完成
+// ctx 到期时取消处理中回调的 context 并返回 ctx.Err()，未完成的消息会在重启后重新处理
+func (in *Inbox) Shutdown(ctx context.Context) error {
+	in.stopOnce.Do(func() {
+		close(in.stop)
+	})
+
+	done := make(chan struct{})
+	go func() {
+		in.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		in.cancel()
+		return nil
+	case <-ctx.Done():
+		in.cancel()
+		return ctx.Err()
+	}
+}
+
+// work worker 主循环
+func (in *Inbox) work() {
+	defer in.wg.Done()
+
+	timer := time.NewTimer(in.pollInterval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-in.stop:
+			return
+		default:
+		}
+
+		msg, err := in.queue.Dequeue(in.ctx, time.Now())
+		if err != nil {
+			in.h.logger.Printf("Dequeue notify failed: %v", err)
+		}
+		if msg != nil {
+			in.handle(msg)
+			continue
+		}
+
+		// 队列为空，等待新消息或轮询
+		timer.Reset(in.pollInterval)
+		select {
+		case <-in.stop:
+			return
+		case <-in.wake:
+		case <-timer.C:
+		}
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+	}
+}
+
+// handle 处理一条消息
+func (in *Inbox) handle(msg *inbox.Message) {
+	msg.Attempts++
+
+	// 重新验签以恢复回调数据（入队前已验签，失败说明密钥已变更，无法再处理）
+	notifyData, err := in.h.client.VerifyNotify(msg.Params)
+	if err != nil {
+		in.h.recordDeadLetter(in.ctx, msg.Params, err)
+	} else {
+		err = in.h.processNotify(in.ctx, &NotifyRequest{
+			Notify:     notifyData,
+			Params:     msg.Params,
+			Source:     NotifySourceInbox,
+			RemoteAddr: msg.RemoteAddr,
+			ReceivedAt: msg.ReceivedAt,
+			Attempt:    msg.Attempts,
+		}, in.fn)
+		if err == nil {
+			if err := in.queue.Ack(in.ctx, msg.ID); err != nil {
+				in.h.logger.Printf("Ack notify %s failed: %v", msg.ID, err)
+			}
+			return
+		}
+	}
+
+	if msg.Attempts >= in.maxAttempts {
+		in.h.logger.Printf("Notify %s (params: %+v) failed after %d attempts, giving up: %v",
+			msg.ID, msg.Params, msg.Attempts, err)
+		if in.h.deadLetters != nil {
+			in.h.logger.Printf("Notify %s kept in dead letter store as %s", msg.ID, inbox.DeadLetterID(msg.Params))
+		}
+		if err := in.queue.Ack(in.ctx, msg.ID); err != nil {
+			in.h.logger.Printf("Ack notify %s failed: %v", msg.ID, err)
+		}
+		return
+	}
+
+	msg.LastError = err.Error()
+	msg.NextAttemptAt = time.Now().Add(in.retryDelay(msg.Attempts))
+	if err := in.queue.Retry(in.ctx, msg); err != nil {
+		in.h.logger.Printf("Reschedule notify %s failed: %v", msg.ID, err)
+	}
+}
+
+// retryDelay 计算第 attempts 次失败后的重试间隔（指数退避）
+func (in *Inbox) retryDelay(attempts int) time.Duration {
+	delay := in.backoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= in.maxBackoff {
+			return in.maxBackoff
+		}
+	}
+	return delay
+}
@@ -0,0 +1,284 @@
+package inbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/liuscraft/epay-sdk-go/internal/journal"
+)
+
+// 每条死信保留的错误历史条数
+const maxFailureHistory = 20
+
+// Failure 一次处理失败记录
+type Failure struct {
+	At    time.Time `json:"at"`    // 失败时间
+	Error string    `json:"error"` // 错误信息
+}
+
+// DeadLetter 业务回调处理失败的回调通知
+type DeadLetter struct {
+	ID            string            `json:"id"`              // 死信ID（trade_no/trade_status）
+	OutTradeNo    string            `json:"out_trade_no"`    // 商户订单号
+	TradeNo       string            `json:"trade_no"`        // EPay订单号
+	TradeStatus   string            `json:"trade_status"`    // 支付状态
+	Params        map[string]string `json:"params"`          // 最近一次的原始回调参数
+	Attempts      int               `json:"attempts"`        // 累计失败次数
+	FirstFailedAt time.Time         `json:"first_failed_at"` // 首次失败时间
+	LastFailedAt  time.Time         `json:"last_failed_at"`  // 最近失败时间
+	Failures      []Failure         `json:"failures"`        // 错误历史（最近 20 条）
+}
+
+// LastError 返回最近一次错误信息
+func (d *DeadLetter) LastError() string {
+	if len(d.Failures) == 0 {
+		return ""
+	}
+	return d.Failures[len(d.Failures)-1].Error
+}
+
+// DeadLetterID 返回回调参数对应的死信ID
+// 同一 trade_no 与 trade_status 的重复回调归为同一条死信
+func DeadLetterID(params map[string]string) string {
+	return params["trade_no"] + "/" + params["trade_status"]
+}
+
+// DeadLetterStore 死信存储
+type DeadLetterStore interface {
+	// Record 记录一次处理失败，同一ID的失败会追加到错误历史
+	Record(ctx context.Context, params map[string]string, cause error) (*DeadLetter, error)
+	// Get 获取死信，不存在时返回 ErrMessageNotFound
+	Get(ctx context.Context, id string) (*DeadLetter, error)
+	// List 按最近失败时间倒序列出死信
+	List(ctx context.Context) ([]*DeadLetter, error)
+	// Delete 删除死信（处理成功或人工放弃），不存在时不报错
+	Delete(ctx context.Context, id string) error
+}
+
+// MemoryDeadLetterStore 内存死信存储（重启后丢失）
+type MemoryDeadLetterStore struct {
+	mu      sync.RWMutex
+	letters map[string]*DeadLetter
+	now     func() time.Time
+}
+
+// NewMemoryDeadLetterStore 创建内存死信存储
+func NewMemoryDeadLetterStore() *MemoryDeadLetterStore {
+	return &MemoryDeadLetterStore{
+		letters: make(map[string]*DeadLetter),
+		now:     time.Now,
+	}
+}
+
+// Record 记录一次处理失败
+func (s *MemoryDeadLetterStore) Record(ctx context.Context, params map[string]string, cause error) (*DeadLetter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	letter := s.next(params, cause)
+	s.letters[letter.ID] = letter
+	return cloneDeadLetter(letter), nil
+}
+
+// Get 获取死信
+func (s *MemoryDeadLetterStore) Get(ctx context.Context, id string) (*DeadLetter, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	letter, ok := s.letters[id]
+	if !ok {
+		return nil, ErrMessageNotFound
+	}
+	return cloneDeadLetter(letter), nil
+}
+
+// List 按最近失败时间倒序列出死信
+func (s *MemoryDeadLetterStore) List(ctx context.Context) ([]*DeadLetter, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	letters := make([]*DeadLetter, 0, len(s.letters))
+	for _, letter := range s.letters {
+		letters = append(letters, cloneDeadLetter(letter))
+	}
+	sort.Slice(letters, func(i, j int) bool {
+		if !letters[i].LastFailedAt.Equal(letters[j].LastFailedAt) {
+			return letters[i].LastFailedAt.After(letters[j].LastFailedAt)
+		}
+		return letters[i].ID < letters[j].ID
+	})
+	return letters, nil
+}
+
+// Delete 删除死信
+func (s *MemoryDeadLetterStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.letters, id)
+	return nil
+}
+
+// next 基于现有记录构建追加失败后的死信（调用方持有锁）
+func (s *MemoryDeadLetterStore) next(params map[string]string, cause error) *DeadLetter {
+	now := s.now()
+	id := DeadLetterID(params)
+
+	letter := &DeadLetter{
+		ID:            id,
+		OutTradeNo:    params["out_trade_no"],
+		TradeNo:       params["trade_no"],
+		TradeStatus:   params["trade_status"],
+		FirstFailedAt: now,
+	}
+	if current, ok := s.letters[id]; ok {
+		letter = cloneDeadLetter(current)
+	}
+
+	letter.Params = cloneParams(params)
+	letter.Attempts++
+	letter.LastFailedAt = now
+	letter.Failures = append(letter.Failures, Failure{At: now, Error: cause.Error()})
+	if len(letter.Failures) > maxFailureHistory {
+		letter.Failures = letter.Failures[len(letter.Failures)-maxFailureHistory:]
+	}
+	return letter
+}
+
+// deadLetterRecord 死信日志记录
+type deadLetterRecord struct {
+	Op     string      `json:"op"`
+	ID     string      `json:"id,omitempty"`
+	Letter *DeadLetter `json:"letter,omitempty"`
+}
+
+// FileDeadLetterStore 基于追加写日志文件的持久化死信存储
+type FileDeadLetterStore struct {
+	mu      sync.Mutex
+	journal *journal.Journal
+	mem     *MemoryDeadLetterStore
+	logger  Logger
+}
+
+// OpenFileDeadLetterStore 打开（或创建）文件死信存储
+func OpenFileDeadLetterStore(path string, opts ...FileOption) (*FileDeadLetterStore, error) {
+	s := &FileDeadLetterStore{
+		mem:    NewMemoryDeadLetterStore(),
+		logger: newFileOptions(opts).logger,
+	}
+
+	j, err := journal.Open("dead letter store", path, s.replay)
+	if err != nil {
+		return nil, err
+	}
+	s.journal = j
+
+	return s, nil
+}
+
+// Record 记录一次处理失败
+func (s *FileDeadLetterStore) Record(ctx context.Context, params map[string]string, cause error) (*DeadLetter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.mem.mu.Lock()
+	defer s.mem.mu.Unlock()
+	letter := s.mem.next(params, cause)
+	if err := s.journal.Append(&deadLetterRecord{Op: opPut, Letter: letter}); err != nil {
+		return nil, err
+	}
+	s.mem.letters[letter.ID] = letter
+	s.maybeCompact()
+	return cloneDeadLetter(letter), nil
+}
+
+// Get 获取死信
+func (s *FileDeadLetterStore) Get(ctx context.Context, id string) (*DeadLetter, error) {
+	return s.mem.Get(ctx, id)
+}
+
+// List 按最近失败时间倒序列出死信
+func (s *FileDeadLetterStore) List(ctx context.Context) ([]*DeadLetter, error) {
+	return s.mem.List(ctx)
+}
+
+// Delete 删除死信
+func (s *FileDeadLetterStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.mem.mu.Lock()
+	defer s.mem.mu.Unlock()
+	if _, ok := s.mem.letters[id]; !ok {
+		return nil
+	}
+	if err := s.journal.Append(&deadLetterRecord{Op: opDelete, ID: id}); err != nil {
+		return err
+	}
+	delete(s.mem.letters, id)
+	s.maybeCompact()
+	return nil
+}
+
+// Close 关闭存储
+func (s *FileDeadLetterStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.journal.Close()
+}
+
+// replay 重放一条日志记录
+func (s *FileDeadLetterStore) replay(line []byte) error {
+	var rec deadLetterRecord
+	if err := json.Unmarshal(line, &rec); err != nil {
+		return err
+	}
+	switch rec.Op {
+	case opPut:
+		if rec.Letter == nil {
+			return fmt.Errorf("missing dead letter")
+		}
+		s.mem.letters[rec.Letter.ID] = rec.Letter
+	case opDelete:
+		delete(s.mem.letters, rec.ID)
+	default:
+		return fmt.Errorf("unknown op %q", rec.Op)
+	}
+	return nil
+}
+
+// maybeCompact 日志冗余过多时压缩（调用方持有 s.mu 和 s.mem.mu）
+// 调用时写入已持久化，压缩失败只记录日志
+func (s *FileDeadLetterStore) maybeCompact() {
+	err := s.journal.MaybeCompact(len(s.mem.letters), func(enc *json.Encoder) (int, error) {
+		for _, letter := range s.mem.letters {
+			if err := enc.Encode(&deadLetterRecord{Op: opPut, Letter: letter}); err != nil {
+				return 0, err
+			}
+		}
+		return len(s.mem.letters), nil
+	})
+	if err != nil {
+		s.logger.Printf("Dead letter store auto compaction failed: %v", err)
+	}
+}
+
+// cloneDeadLetter 复制死信
+func cloneDeadLetter(letter *DeadLetter) *DeadLetter {
+	c := *letter
+	c.Params = cloneParams(letter.Params)
+	c.Failures = append([]Failure(nil), letter.Failures...)
+	return &c
+}
+
+// cloneParams 复制回调参数
+func cloneParams(params map[string]string) map[string]string {
+	c := make(map[string]string, len(params))
+	for k, v := range params {
+		c[k] = v
+	}
+	return c
+}
@@ -0,0 +1,133 @@
+package inbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testDeadLetterStore(t *testing.T, store DeadLetterStore) {
+	ctx := context.Background()
+	params := map[string]string{"trade_no": "T1", "out_trade_no": "O1", "trade_status": "TRADE_SUCCESS"}
+
+	for i := 1; i <= maxFailureHistory+5; i++ {
+		letter, err := store.Record(ctx, params, fmt.Errorf("attempt %d", i))
+		if err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+		if letter.Attempts != i {
+			t.Fatalf("Attempts = %d, want %d", letter.Attempts, i)
+		}
+	}
+	store.Record(ctx, map[string]string{"trade_no": "T2", "trade_status": "TRADE_SUCCESS"}, errors.New("boom"))
+
+	letter, err := store.Get(ctx, "T1/TRADE_SUCCESS")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if len(letter.Failures) != maxFailureHistory {
+		t.Errorf("len(Failures) = %d, want %d", len(letter.Failures), maxFailureHistory)
+	}
+	if got := letter.LastError(); got != fmt.Sprintf("attempt %d", maxFailureHistory+5) {
+		t.Errorf("LastError() = %q", got)
+	}
+	if letter.OutTradeNo != "O1" || letter.Params["trade_no"] != "T1" {
+		t.Errorf("Get() = %+v", letter)
+	}
+
+	letters, err := store.List(ctx)
+	if err != nil || len(letters) != 2 || letters[0].TradeNo != "T2" {
+		t.Fatalf("List() = %v, %v, want T2 first", letters, err)
+	}
+
+	if err := store.Delete(ctx, letter.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := store.Get(ctx, letter.ID); !errors.Is(err, ErrMessageNotFound) {
+		t.Errorf("Get() after delete error = %v, want ErrMessageNotFound", err)
+	}
+}
+
+// tickingClock 每次调用前进一秒的时钟，保证失败时间有序
+func tickingClock() func() time.Time {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	return func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+}
+
+func TestMemoryDeadLetterStore(t *testing.T) {
+	store := NewMemoryDeadLetterStore()
+	store.now = tickingClock()
+	testDeadLetterStore(t, store)
+}
+
+func TestFileDeadLetterStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dead.log")
+	store, err := OpenFileDeadLetterStore(path)
+	if err != nil {
+		t.Fatalf("OpenFileDeadLetterStore() error = %v", err)
+	}
+	store.mem.now = tickingClock()
+	testDeadLetterStore(t, store)
+	store.Close()
+
+	reopened, err := OpenFileDeadLetterStore(path)
+	if err != nil {
+		t.Fatalf("OpenFileDeadLetterStore() reopen error = %v", err)
+	}
+	defer reopened.Close()
+
+	letters, err := reopened.List(context.Background())
+	if err != nil || len(letters) != 1 || letters[0].TradeNo != "T2" || letters[0].LastError() != "boom" {
+		t.Fatalf("List() after reopen = %+v, %v", letters, err)
+	}
+}
+
+func TestFileDeadLetterStore_CompactFailure(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "dead.log")
+	logger := &recordLogger{}
+	store, err := OpenFileDeadLetterStore(path, WithFileLogger(logger))
+	if err != nil {
+		t.Fatalf("OpenFileDeadLetterStore() error = %v", err)
+	}
+
+	// 占用压缩临时文件路径，使自动压缩失败
+	if err := os.Mkdir(path+".compact", 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	// 写入已持久化，压缩失败不影响 Record/Delete 的结果，
+	// 调用方不会因误报失败而重试已生效的删除
+	for i := 0; i < 100; i++ {
+		params := map[string]string{"trade_no": fmt.Sprintf("T%d", i), "trade_status": "TRADE_SUCCESS"}
+		letter, err := store.Record(ctx, params, errors.New("boom"))
+		if err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+		if err := store.Delete(ctx, letter.ID); err != nil {
+			t.Fatalf("Delete() error = %v", err)
+		}
+	}
+	store.Record(ctx, map[string]string{"trade_no": "KEEP", "trade_status": "TRADE_SUCCESS"}, errors.New("boom"))
+	if len(logger.lines) == 0 {
+		t.Error("auto compaction failure not logged to configured logger")
+	}
+	store.Close()
+
+	reopened, err := OpenFileDeadLetterStore(path)
+	if err != nil {
+		t.Fatalf("OpenFileDeadLetterStore() reopen error = %v", err)
+	}
+	defer reopened.Close()
+	letters, err := reopened.List(ctx)
+	if err != nil || len(letters) != 1 || letters[0].TradeNo != "KEEP" {
+		t.Fatalf("List() after reopen = %+v, %v", letters, err)
+	}
+}
@@ -0,0 +1,207 @@
+package inbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/liuscraft/epay-sdk-go/internal/journal"
+)
+
+// 日志记录类型
+const (
+	opPut    = "put"    // 写入或更新记录
+	opAck    = "ack"    // 删除消息
+	opDelete = "delete" // 删除死信
+)
+
+// record 队列日志记录
+type record struct {
+	Op  string   `json:"op"`
+	ID  string   `json:"id,omitempty"`
+	Msg *Message `json:"msg,omitempty"`
+}
+
+// Logger 日志接口
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// fileOptions 文件队列与文件死信存储的公共配置
+type fileOptions struct {
+	logger Logger
+}
+
+// FileOption 文件队列与文件死信存储的配置选项
+type FileOption func(*fileOptions)
+
+// WithFileLogger 设置日志器（默认 log.Default()），用于记录自动压缩失败等不影响写入结果的错误
+func WithFileLogger(logger Logger) FileOption {
+	return func(o *fileOptions) {
+		o.logger = logger
+	}
+}
+
+// newFileOptions 应用配置选项
+func newFileOptions(opts []FileOption) *fileOptions {
+	o := &fileOptions{logger: log.Default()}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// FileQueue 基于追加写日志文件的持久化消息队列
+// 每次写入都会追加 JSON 行并 fsync，启动时重放日志恢复队列；
+// 重启前已取出但未确认的消息会重新投递。
+type FileQueue struct {
+	mu      sync.Mutex
+	journal *journal.Journal
+	mem     *MemoryQueue
+	logger  Logger
+}
+
+// OpenFileQueue 打开（或创建）文件消息队列
+func OpenFileQueue(path string, opts ...FileOption) (*FileQueue, error) {
+	q := &FileQueue{
+		mem:    NewMemoryQueue(),
+		logger: newFileOptions(opts).logger,
+	}
+
+	j, err := journal.Open("inbox queue", path, q.replay)
+	if err != nil {
+		return nil, err
+	}
+	q.journal = j
+
+	return q, nil
+}
+
+// Enqueue 写入消息
+func (q *FileQueue) Enqueue(ctx context.Context, msg *Message) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.mem.mu.Lock()
+	defer q.mem.mu.Unlock()
+	if err := q.journal.Append(&record{Op: opPut, Msg: msg}); err != nil {
+		return err
+	}
+	q.mem.pending[msg.ID] = cloneMessage(msg)
+	q.maybeCompact()
+	return nil
+}
+
+// Dequeue 取出一条可处理的消息
+func (q *FileQueue) Dequeue(ctx context.Context, now time.Time) (*Message, error) {
+	return q.mem.Dequeue(ctx, now)
+}
+
+// Ack 确认消息处理完成
+func (q *FileQueue) Ack(ctx context.Context, id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.mem.mu.Lock()
+	defer q.mem.mu.Unlock()
+	if _, ok := q.mem.inflight[id]; !ok {
+		return ErrMessageNotFound
+	}
+	if err := q.journal.Append(&record{Op: opAck, ID: id}); err != nil {
+		return err
+	}
+	q.mem.ack(id)
+	q.maybeCompact()
+	return nil
+}
+
+// Retry 将消息放回队列
+func (q *FileQueue) Retry(ctx context.Context, msg *Message) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.mem.mu.Lock()
+	defer q.mem.mu.Unlock()
+	if _, ok := q.mem.inflight[msg.ID]; !ok {
+		return ErrMessageNotFound
+	}
+	if err := q.journal.Append(&record{Op: opPut, Msg: msg}); err != nil {
+		return err
+	}
+	q.mem.retry(msg)
+	q.maybeCompact()
+	return nil
+}
+
+// Len 返回队列中的消息数（含处理中）
+func (q *FileQueue) Len() int {
+	return q.mem.Len()
+}
+
+// Compact 压缩日志文件，只保留未确认的消息
+func (q *FileQueue) Compact() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.mem.mu.Lock()
+	defer q.mem.mu.Unlock()
+	return q.compact()
+}
+
+// Close 关闭队列
+func (q *FileQueue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.journal.Close()
+}
+
+// replay 重放一条日志记录
+func (q *FileQueue) replay(line []byte) error {
+	var rec record
+	if err := json.Unmarshal(line, &rec); err != nil {
+		return err
+	}
+	switch rec.Op {
+	case opPut:
+		if rec.Msg == nil {
+			return fmt.Errorf("missing message")
+		}
+		q.mem.pending[rec.Msg.ID] = rec.Msg
+	case opAck:
+		delete(q.mem.pending, rec.ID)
+	default:
+		return fmt.Errorf("unknown op %q", rec.Op)
+	}
+	return nil
+}
+
+// maybeCompact 日志冗余过多时压缩（调用方持有 q.mu 和 q.mem.mu）
+// 调用时写入已持久化，压缩失败只记录日志
+func (q *FileQueue) maybeCompact() {
+	if err := q.journal.MaybeCompact(len(q.mem.pending)+len(q.mem.inflight), q.writeLive); err != nil {
+		q.logger.Printf("Inbox queue auto compaction failed: %v", err)
+	}
+}
+
+// compact 重写日志文件（调用方持有 q.mu 和 q.mem.mu）
+func (q *FileQueue) compact() error {
+	return q.journal.Rewrite(q.writeLive)
+}
+
+// writeLive 写出未确认的消息（调用方持有 q.mem.mu）
+// 处理中的消息同样写入，以便重启后重新投递
+func (q *FileQueue) writeLive(enc *json.Encoder) (int, error) {
+	live := 0
+	for _, set := range []map[string]*Message{q.mem.pending, q.mem.inflight} {
+		for _, msg := range set {
+			if err := enc.Encode(&record{Op: opPut, Msg: msg}); err != nil {
+				return 0, err
+			}
+			live++
+		}
+	}
+	return live, nil
+}
@@ -0,0 +1,163 @@
+// Package inbox 提供回调通知的持久化收件箱队列
+// 回调验签后先写入队列并立即向 EPay 返回 "success"，再由后台 worker 异步处理，
+// 避免耗时的业务逻辑超过 EPay 的回调超时而触发不必要的重试。
+// 业务回调处理失败的通知记录在死信存储（DeadLetterStore）中，可查看错误历史并人工重放。
+//
+// 使用示例:
+//
+//	queue, err := inbox.OpenFileQueue("/var/lib/myapp/inbox.log")
+//	in := handlers.NewInbox(queue, callback)
+//	in.Start()
+//	defer in.Shutdown(ctx)
+//	http.Handle("/notify", in.Notify())
+package inbox
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrMessageNotFound 消息不存在
+var ErrMessageNotFound = errors.New("inbox: message not found")
+
+// Message 收件箱中的回调消息
+type Message struct {
+	ID            string            `json:"id"`                    // 消息ID
+	Params        map[string]string `json:"params"`                // 原始回调参数
+	RemoteAddr    string            `json:"remote_addr,omitempty"` // 回调来源地址
+	ReceivedAt    time.Time         `json:"received_at"`           // 接收时间
+	Attempts      int               `json:"attempts"`              // 已处理次数
+	NextAttemptAt time.Time         `json:"next_attempt_at"`       // 下次可处理时间
+	LastError     string            `json:"last_error,omitempty"`  // 最近一次处理错误
+}
+
+// Queue 回调消息队列
+// Dequeue 取出的消息在 Ack 或 Retry 之前不会被再次取出；
+// 持久化实现在重启后应重新投递未确认的消息（至少一次）
+type Queue interface {
+	// Enqueue 写入消息，返回前必须已持久化
+	Enqueue(ctx context.Context, msg *Message) error
+	// Dequeue 取出一条 NextAttemptAt 不晚于 now 的消息，无可处理消息时返回 (nil, nil)
+	Dequeue(ctx context.Context, now time.Time) (*Message, error)
+	// Ack 确认消息处理完成并删除
+	Ack(ctx context.Context, id string) error
+	// Retry 将已取出的消息放回队列，等待 NextAttemptAt 后重新处理
+	Retry(ctx context.Context, msg *Message) error
+}
+
+// idSeq 消息ID序号
+var idSeq atomic.Uint64
+
+// NewMessage 创建回调消息
+func NewMessage(params map[string]string) *Message {
+	now := time.Now()
+	return &Message{
+		ID:            strconv.FormatInt(now.UnixNano(), 36) + "-" + strconv.FormatUint(idSeq.Add(1), 36),
+		Params:        params,
+		ReceivedAt:    now,
+		NextAttemptAt: now,
+	}
+}
+
+// MemoryQueue 内存消息队列（非持久化，适用于测试）
+type MemoryQueue struct {
+	mu       sync.Mutex
+	pending  map[string]*Message
+	inflight map[string]*Message
+}
+
+// NewMemoryQueue 创建内存消息队列
+func NewMemoryQueue() *MemoryQueue {
+	return &MemoryQueue{
+		pending:  make(map[string]*Message),
+		inflight: make(map[string]*Message),
+	}
+}
+
+// Enqueue 写入消息
+func (q *MemoryQueue) Enqueue(ctx context.Context, msg *Message) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.pending[msg.ID] = cloneMessage(msg)
+	return nil
+}
+
+// Dequeue 取出一条可处理的消息
+func (q *MemoryQueue) Dequeue(ctx context.Context, now time.Time) (*Message, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	msg := q.next(now)
+	if msg == nil {
+		return nil, nil
+	}
+	delete(q.pending, msg.ID)
+	q.inflight[msg.ID] = msg
+	return cloneMessage(msg), nil
+}
+
+// Ack 确认消息处理完成
+func (q *MemoryQueue) Ack(ctx context.Context, id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.ack(id)
+}
+
+// Retry 将消息放回队列
+func (q *MemoryQueue) Retry(ctx context.Context, msg *Message) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.retry(msg)
+}
+
+// Len 返回队列中的消息数（含处理中）
+func (q *MemoryQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending) + len(q.inflight)
+}
+
+// next 返回最早可处理的消息（调用方持有锁）
+func (q *MemoryQueue) next(now time.Time) *Message {
+	var best *Message
+	for _, msg := range q.pending {
+		if msg.NextAttemptAt.After(now) {
+			continue
+		}
+		if best == nil || msg.NextAttemptAt.Before(best.NextAttemptAt) ||
+			(msg.NextAttemptAt.Equal(best.NextAttemptAt) && msg.ID < best.ID) {
+			best = msg
+		}
+	}
+	return best
+}
+
+// ack 删除处理中的消息（调用方持有锁）
+func (q *MemoryQueue) ack(id string) error {
+	if _, ok := q.inflight[id]; !ok {
+		return ErrMessageNotFound
+	}
+	delete(q.inflight, id)
+	return nil
+}
+
+// retry 将处理中的消息放回待处理（调用方持有锁）
+func (q *MemoryQueue) retry(msg *Message) error {
+	if _, ok := q.inflight[msg.ID]; !ok {
+		return ErrMessageNotFound
+	}
+	delete(q.inflight, msg.ID)
+	q.pending[msg.ID] = cloneMessage(msg)
+	return nil
+}
+
+// cloneMessage 复制消息，避免调用方修改队列内部状态
+func cloneMessage(msg *Message) *Message {
+	c := *msg
+	c.Params = cloneParams(msg.Params)
+	return &c
+}
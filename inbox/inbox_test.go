@@ -0,0 +1,210 @@
+package inbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testQueue(t *testing.T, queue Queue) {
+	ctx := context.Background()
+
+	first := NewMessage(map[string]string{"trade_no": "T1"})
+	second := NewMessage(map[string]string{"trade_no": "T2"})
+	now := time.Now()
+	for _, msg := range []*Message{first, second} {
+		if err := queue.Enqueue(ctx, msg); err != nil {
+			t.Fatalf("Enqueue() error = %v", err)
+		}
+	}
+
+	msg, err := queue.Dequeue(ctx, now)
+	if err != nil || msg == nil || msg.ID != first.ID {
+		t.Fatalf("Dequeue() = %v, %v, want first message", msg, err)
+	}
+
+	// 重试的消息在 NextAttemptAt 之前不会被取出
+	msg.Attempts++
+	msg.NextAttemptAt = now.Add(time.Minute)
+	msg.LastError = "boom"
+	if err := queue.Retry(ctx, msg); err != nil {
+		t.Fatalf("Retry() error = %v", err)
+	}
+
+	msg, err = queue.Dequeue(ctx, now)
+	if err != nil || msg == nil || msg.ID != second.ID {
+		t.Fatalf("Dequeue() = %v, %v, want second message", msg, err)
+	}
+	if err := queue.Ack(ctx, msg.ID); err != nil {
+		t.Fatalf("Ack() error = %v", err)
+	}
+	if err := queue.Ack(ctx, msg.ID); !errors.Is(err, ErrMessageNotFound) {
+		t.Errorf("Ack() twice error = %v, want ErrMessageNotFound", err)
+	}
+
+	if msg, _ := queue.Dequeue(ctx, now); msg != nil {
+		t.Fatalf("Dequeue() = %v, want nil before retry time", msg)
+	}
+	msg, err = queue.Dequeue(ctx, now.Add(2*time.Minute))
+	if err != nil || msg == nil || msg.ID != first.ID || msg.Attempts != 1 || msg.LastError != "boom" {
+		t.Fatalf("Dequeue() = %+v, %v, want retried first message", msg, err)
+	}
+}
+
+func TestMemoryQueue(t *testing.T) {
+	testQueue(t, NewMemoryQueue())
+}
+
+func TestFileQueue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "inbox.log")
+	queue, err := OpenFileQueue(path)
+	if err != nil {
+		t.Fatalf("OpenFileQueue() error = %v", err)
+	}
+	testQueue(t, queue)
+	queue.Close()
+
+	// 重启后未确认的消息（包括处理中的）重新投递
+	reopened, err := OpenFileQueue(path)
+	if err != nil {
+		t.Fatalf("OpenFileQueue() reopen error = %v", err)
+	}
+	defer reopened.Close()
+	if reopened.Len() != 1 {
+		t.Fatalf("Len() = %d, want 1", reopened.Len())
+	}
+	msg, err := reopened.Dequeue(context.Background(), time.Now().Add(time.Hour))
+	if err != nil || msg == nil || msg.Params["trade_no"] != "T1" || msg.Attempts != 1 {
+		t.Fatalf("Dequeue() after reopen = %+v, %v", msg, err)
+	}
+}
+
+func TestFileQueue_Compact(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "inbox.log")
+	queue, err := OpenFileQueue(path)
+	if err != nil {
+		t.Fatalf("OpenFileQueue() error = %v", err)
+	}
+
+	for i := 0; i < 100; i++ {
+		queue.Enqueue(ctx, NewMessage(map[string]string{"i": "x"}))
+		msg, _ := queue.Dequeue(ctx, time.Now())
+		if err := queue.Ack(ctx, msg.ID); err != nil {
+			t.Fatalf("Ack() error = %v", err)
+		}
+	}
+	keep := NewMessage(map[string]string{"trade_no": "KEEP"})
+	queue.Enqueue(ctx, keep)
+	if queue.journal.Entries() >= 200 {
+		t.Errorf("entries = %d, want auto compaction", queue.journal.Entries())
+	}
+	queue.Close()
+
+	reopened, err := OpenFileQueue(path)
+	if err != nil {
+		t.Fatalf("OpenFileQueue() reopen error = %v", err)
+	}
+	defer reopened.Close()
+	if reopened.Len() != 1 {
+		t.Errorf("Len() after reopen = %d, want 1", reopened.Len())
+	}
+}
+
+// recordLogger 记录日志内容的 Logger
+type recordLogger struct {
+	lines []string
+}
+
+func (l *recordLogger) Printf(format string, v ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprintf(format, v...))
+}
+
+func TestFileQueue_CompactFailure(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "inbox.log")
+	logger := &recordLogger{}
+	queue, err := OpenFileQueue(path, WithFileLogger(logger))
+	if err != nil {
+		t.Fatalf("OpenFileQueue() error = %v", err)
+	}
+
+	// 占用压缩临时文件路径，使自动压缩失败
+	if err := os.Mkdir(path+".compact", 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	// 写入已持久化，压缩失败不影响操作结果
+	for i := 0; i < 100; i++ {
+		if err := queue.Enqueue(ctx, NewMessage(map[string]string{"i": "x"})); err != nil {
+			t.Fatalf("Enqueue() error = %v", err)
+		}
+		msg, _ := queue.Dequeue(ctx, time.Now())
+		if err := queue.Ack(ctx, msg.ID); err != nil {
+			t.Fatalf("Ack() error = %v", err)
+		}
+	}
+	keep := NewMessage(map[string]string{"trade_no": "KEEP"})
+	if err := queue.Enqueue(ctx, keep); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	if err := queue.Compact(); err == nil {
+		t.Error("explicit Compact() should report the failure")
+	}
+	if len(logger.lines) == 0 {
+		t.Error("auto compaction failure not logged to configured logger")
+	}
+	queue.Close()
+
+	reopened, err := OpenFileQueue(path)
+	if err != nil {
+		t.Fatalf("OpenFileQueue() reopen error = %v", err)
+	}
+	defer reopened.Close()
+	if reopened.Len() != 1 {
+		t.Errorf("Len() after reopen = %d, want 1", reopened.Len())
+	}
+}
+
+func TestFileQueue_TornTail(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "inbox.log")
+	queue, err := OpenFileQueue(path)
+	if err != nil {
+		t.Fatalf("OpenFileQueue() error = %v", err)
+	}
+	queue.Enqueue(ctx, NewMessage(map[string]string{"trade_no": "T1"}))
+	queue.Close()
+
+	// 模拟写入中途崩溃留下的半行记录
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"op":"put","msg":{"id":"torn`)
+	f.Close()
+
+	reopened, err := OpenFileQueue(path)
+	if err != nil {
+		t.Fatalf("OpenFileQueue() with torn tail error = %v", err)
+	}
+	if reopened.Len() != 1 {
+		t.Errorf("Len() = %d, want 1", reopened.Len())
+	}
+	reopened.Enqueue(ctx, NewMessage(map[string]string{"trade_no": "T2"}))
+	reopened.Close()
+
+	// 残缺记录已截断，之后的写入不会与其连成一行
+	again, err := OpenFileQueue(path)
+	if err != nil {
+		t.Fatalf("OpenFileQueue() after torn tail error = %v", err)
+	}
+	defer again.Close()
+	if again.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", again.Len())
+	}
+}
@@ -0,0 +1,238 @@
+// Package journal 提供追加写 JSON 行日志文件
+// tracker.FileStore、inbox.FileQueue 和 inbox.FileDeadLetterStore 共用：
+// 每次写入追加一行 JSON 并 fsync，启动时按顺序重放，冗余过多时重写压缩。
+package journal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// DefaultCompactRatio 触发自动压缩的日志冗余倍数
+const DefaultCompactRatio = 4
+
+// 触发自动压缩的最少记录行数
+const minCompactEntries = 64
+
+// 单行记录的最大长度
+const maxLineSize = 16 * 1024 * 1024
+
+// Journal 追加写 JSON 行日志文件（非并发安全，由调用方加锁）
+type Journal struct {
+	name    string // 用于错误信息
+	path    string
+	file    *os.File
+	size    int64 // 已完整写入的文件长度
+	entries int   // 日志中的记录行数
+	ratio   int
+	broken  error // 写入失败且未能回滚时的错误，之后的写入直接返回
+}
+
+// Open 打开（或创建）日志文件，按顺序对每一行调用 replay
+// 崩溃时写了一半的最后一行会被截断丢弃（该写入从未向调用方返回成功）。
+func Open(name, path string, replay func(line []byte) error) (*Journal, error) {
+	j := &Journal{
+		name:  name,
+		path:  path,
+		ratio: DefaultCompactRatio,
+	}
+
+	if err := j.load(replay); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", name, err)
+	}
+	j.file = file
+
+	return j, nil
+}
+
+// Entries 返回日志中的记录行数
+func (j *Journal) Entries() int {
+	return j.entries
+}
+
+// load 重放日志文件，截断不完整的末尾记录
+func (j *Journal) load(replay func(line []byte) error) error {
+	file, err := os.OpenFile(j.path, os.O_RDWR, 0)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("open %s: %w", j.name, err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReaderSize(file, 64*1024)
+	var offset int64
+	line := 0
+	for {
+		data, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(data) > 0 {
+				// 末尾没有换行符：写入中途崩溃留下的残缺记录
+				if err := file.Truncate(offset); err != nil {
+					return fmt.Errorf("truncate %s: %w", j.name, err)
+				}
+			}
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("read %s: %w", j.name, err)
+		}
+		if len(data) > maxLineSize {
+			return fmt.Errorf("%s %s line %d: record too large", j.name, j.path, line+1)
+		}
+
+		line++
+		offset += int64(len(data))
+		data = bytes.TrimRight(data, "\r\n")
+		if len(data) == 0 {
+			continue
+		}
+		if err := replay(data); err != nil {
+			return fmt.Errorf("%s %s line %d: %w", j.name, j.path, line, err)
+		}
+		j.entries++
+	}
+
+	j.size = offset
+	return nil
+}
+
+// Append 追加一条记录并 fsync
+// 写入失败时回滚到写入前的长度，避免残缺记录与下一条记录连在一起
+func (j *Journal) Append(v any) error {
+	if j.broken != nil {
+		return fmt.Errorf("write %s: %w", j.name, j.broken)
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	if _, err := j.file.Write(data); err != nil {
+		j.rollback()
+		return fmt.Errorf("write %s: %w", j.name, err)
+	}
+	if err := j.file.Sync(); err != nil {
+		j.rollback()
+		return fmt.Errorf("sync %s: %w", j.name, err)
+	}
+	j.size += int64(len(data))
+	j.entries++
+	return nil
+}
+
+// rollback 截断到最后一条完整记录
+func (j *Journal) rollback() {
+	if err := j.file.Truncate(j.size); err != nil {
+		j.broken = fmt.Errorf("journal left in inconsistent state, reopen required: %w", err)
+	}
+}
+
+// ShouldCompact 日志行数超过存活记录数的若干倍时需要压缩
+func (j *Journal) ShouldCompact(live int) bool {
+	return j.entries > live*j.ratio && j.entries >= minCompactEntries
+}
+
+// MaybeCompact 需要时压缩日志，返回压缩失败的错误
+// 调用方的写入已经持久化，旧日志仍然完整可用，调用方应只记录该错误而不是让写入失败
+func (j *Journal) MaybeCompact(live int, write func(enc *json.Encoder) (int, error)) error {
+	if !j.ShouldCompact(live) {
+		return nil
+	}
+	return j.Rewrite(write)
+}
+
+// Rewrite 将 write 写出的记录写入临时文件后原子替换日志文件
+// write 返回写出的记录数
+func (j *Journal) Rewrite(write func(enc *json.Encoder) (int, error)) error {
+	tmpPath := j.path + ".compact"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("compact %s: %w", j.name, err)
+	}
+
+	counter := &countingWriter{w: tmp}
+	writer := bufio.NewWriter(counter)
+	live, err := write(json.NewEncoder(writer))
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("compact %s: %w", j.name, err)
+	}
+
+	if err := os.Rename(tmpPath, j.path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("compact %s: %w", j.name, err)
+	}
+	// fsync 所在目录使重命名持久化，否则崩溃后可能恢复为旧日志，而之后的写入已追加到新文件
+	dirErr := syncDir(filepath.Dir(j.path))
+
+	// 重新打开追加写句柄
+	file, err := os.OpenFile(j.path, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		j.broken = fmt.Errorf("reopen after compaction: %w", err)
+		return fmt.Errorf("compact %s: %w", j.name, err)
+	}
+	j.file.Close()
+	j.file = file
+	j.size = counter.n
+	j.entries = live
+	j.broken = nil
+
+	if dirErr != nil {
+		return fmt.Errorf("compact %s: sync dir: %w", j.name, dirErr)
+	}
+	return nil
+}
+
+// syncDir fsync 目录，使其中的创建、重命名持久化
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	err = d.Sync()
+	if closeErr := d.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Close 关闭日志文件
+func (j *Journal) Close() error {
+	return j.file.Close()
+}
+
+// countingWriter 统计写入字节数
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,104 @@
+package journal
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type entry struct {
+	N int `json:"n"`
+}
+
+func replayInto(got *[]int) func(line []byte) error {
+	return func(line []byte) error {
+		var e entry
+		if err := json.Unmarshal(line, &e); err != nil {
+			return err
+		}
+		*got = append(*got, e.N)
+		return nil
+	}
+}
+
+func TestJournal_TornTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.log")
+	if err := os.WriteFile(path, []byte("{\"n\":1}\n{\"n\":2}\n{\"n\":"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []int
+	j, err := Open("test", path, replayInto(&got))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if len(got) != 2 || j.Entries() != 2 {
+		t.Fatalf("replayed %v, entries %d, want [1 2]", got, j.Entries())
+	}
+	if err := j.Append(entry{N: 3}); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	j.Close()
+
+	data, _ := os.ReadFile(path)
+	if want := "{\"n\":1}\n{\"n\":2}\n{\"n\":3}\n"; string(data) != want {
+		t.Errorf("file = %q, want %q", data, want)
+	}
+}
+
+func TestJournal_CorruptMiddleLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.log")
+	if err := os.WriteFile(path, []byte("{\"n\":1}\n{\"n\":\n{\"n\":3}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []int
+	if _, err := Open("test", path, replayInto(&got)); err == nil {
+		t.Fatal("Open() error = nil, want error for corrupt complete line")
+	}
+}
+
+func TestJournal_Compact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.log")
+	var got []int
+	j, err := Open("test", path, replayInto(&got))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	for i := 0; i < 100; i++ {
+		if err := j.Append(entry{N: i}); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+		err := j.MaybeCompact(1, func(enc *json.Encoder) (int, error) {
+			return 1, enc.Encode(entry{N: i})
+		})
+		if err != nil {
+			t.Fatalf("MaybeCompact() error = %v", err)
+		}
+	}
+	if j.Entries() >= 100 {
+		t.Errorf("entries = %d, want auto compaction", j.Entries())
+	}
+
+	// 压缩后继续追加，记录长度正确，重新打开可完整重放
+	if err := j.Append(entry{N: 100}); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	want := j.Entries()
+	j.Close()
+
+	got = nil
+	reopened, err := Open("test", path, replayInto(&got))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer reopened.Close()
+	if len(got) != want || got[len(got)-1] != 100 {
+		t.Errorf("replayed %v, want %d entries ending with 100", got, want)
+	}
+	if info, _ := os.Stat(path); info.Size() != reopened.size {
+		t.Errorf("size = %d, file size = %d", reopened.size, info.Size())
+	}
+}
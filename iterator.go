@@ -0,0 +1,117 @@
+package epay
+
+import "context"
+
+// 订单迭代器默认每页数量
+const defaultIteratorPageSize = 100
+
+// OrderIterator 订单分页迭代器
+// 基于 QueryOrders 逐页拉取商户订单，遇到不足一页的返回即停止。
+// 使用示例:
+//
+//	it := client.IterateOrders(100)
+//	for it.Next(ctx) {
+//	    order := it.Order()
+//	    // ...
+//	}
+//	if err := it.Err(); err != nil {
+//	    // 处理错误
+//	}
+type OrderIterator struct {
+	client   *Client
+	pageSize int
+	page     int // 下一次拉取的页码
+
+	buf     []OrderDetail
+	idx     int
+	current *OrderDetail
+
+	lastPage bool // 已拉取到最后一页
+	stopped  bool
+	err      error
+}
+
+// IterateOrders 创建订单分页迭代器
+// pageSize 为每页数量（1~100，<=0 时默认 100）
+func (c *Client) IterateOrders(pageSize int) *OrderIterator {
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = defaultIteratorPageSize
+	}
+	return &OrderIterator{
+		client:   c,
+		pageSize: pageSize,
+		page:     1,
+	}
+}
+
+// Next 移动到下一个订单，没有更多订单或出错时返回 false
+// 网络错误按客户端的重试策略重试
+func (it *OrderIterator) Next(ctx context.Context) bool {
+	if it.stopped || it.err != nil {
+		return false
+	}
+
+	for it.idx >= len(it.buf) {
+		if it.lastPage {
+			it.current = nil
+			return false
+		}
+		if err := it.fetch(ctx); err != nil {
+			it.err = err
+			it.current = nil
+			return false
+		}
+	}
+
+	it.current = &it.buf[it.idx]
+	it.idx++
+	return true
+}
+
+// Order 返回当前订单
+func (it *OrderIterator) Order() *OrderDetail {
+	return it.current
+}
+
+// Err 返回迭代过程中遇到的错误
+func (it *OrderIterator) Err() error {
+	return it.err
+}
+
+// Page 返回当前订单所在的页码
+func (it *OrderIterator) Page() int {
+	return it.page - 1
+}
+
+// Stop 提前结束迭代，之后 Next 始终返回 false
+func (it *OrderIterator) Stop() {
+	it.stopped = true
+	it.current = nil
+}
+
+// fetch 拉取下一页订单
+func (it *OrderIterator) fetch(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var resp *OrderListResponse
+	err := it.client.withRetry(ctx, func() error {
+		var err error
+		resp, err = it.client.QueryOrdersContext(ctx, it.pageSize, it.page)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	it.buf = resp.Orders
+	it.idx = 0
+	it.page++
+
+	// 不足一页说明已到最后一页
+	if len(resp.Orders) < it.pageSize {
+		it.lastPage = true
+	}
+	return nil
+}
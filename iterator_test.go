@@ -0,0 +1,91 @@
+package epay
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// ordersPageHandler 模拟共 total 个订单的分页接口，第一次请求断开连接
+func ordersPageHandler(t *testing.T, total int, failFirst bool) http.HandlerFunc {
+	var calls int32
+	return func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 && failFirst {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				t.Errorf("Hijack() error = %v", err)
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			conn.Close()
+			return
+		}
+
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+
+		var orders []string
+		for i := (page - 1) * limit; i < page*limit && i < total; i++ {
+			orders = append(orders, fmt.Sprintf(`{"trade_no":"T%03d","out_trade_no":"ORDER%03d","money":"1.00","status":1}`, i, i))
+		}
+		fmt.Fprintf(w, `{"code":1,"count":%d,"orders":[%s]}`, len(orders), strings.Join(orders, ","))
+	}
+}
+
+func TestOrderIterator(t *testing.T) {
+	client := newTestClient(t, ordersPageHandler(t, 25, true))
+	client.config.MaxRetries = 2
+	client.config.RetryInterval = 1
+
+	it := client.IterateOrders(10)
+	count := 0
+	for it.Next(context.Background()) {
+		if want := fmt.Sprintf("ORDER%03d", count); it.Order().OutTradeNo != want {
+			t.Errorf("Order().OutTradeNo = %s, want %s", it.Order().OutTradeNo, want)
+		}
+		count++
+	}
+
+	if err := it.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+	if count != 25 {
+		t.Errorf("iterated %d orders, want 25", count)
+	}
+	if it.Page() != 3 {
+		t.Errorf("Page() = %d, want 3", it.Page())
+	}
+}
+
+func TestOrderIterator_Stop(t *testing.T) {
+	client := newTestClient(t, ordersPageHandler(t, 25, false))
+
+	it := client.IterateOrders(10)
+	count := 0
+	for it.Next(context.Background()) {
+		count++
+		if count == 12 {
+			it.Stop()
+		}
+	}
+
+	if count != 12 {
+		t.Errorf("iterated %d orders, want 12", count)
+	}
+}
+
+func TestOrderIterator_NoRetry(t *testing.T) {
+	client := newTestClient(t, ordersPageHandler(t, 25, true))
+
+	it := client.IterateOrders(10)
+	if it.Next(context.Background()) {
+		t.Fatal("Next() = true, want false without retries")
+	}
+	if !IsRetryable(it.Err()) {
+		t.Errorf("Err() = %v, want network error", it.Err())
+	}
+}
@@ -0,0 +1,43 @@
+package epay
+
+// API 接口路径
+const (
+	APIPathMerchant = "/api.php" // 商户信息查询接口
+)
+
+// QueryMerchant 查询商户信息
+// 返回商户状态、余额、结算账户、费率及订单统计
+func (c *Client) QueryMerchant() (*MerchantInfo, error) {
+	// 构建请求参数
+	params := c.buildBaseParams()
+	params["act"] = "query"
+
+	// 发送请求
+	body, err := c.doGet(APIPathMerchant, params)
+	if err != nil {
+		return nil, err
+	}
+
+	// 解析响应
+	resp, err := parseJSONResponse[MerchantInfo](body)
+	if err != nil {
+		return nil, err
+	}
+
+	// 检查业务错误
+	if resp.Code != 1 {
+		return nil, NewError(ErrCodeAPIError, resp.Msg)
+	}
+
+	return resp, nil
+}
+
+// IsMerchantActive 检查商户是否处于正常状态
+func IsMerchantActive(info *MerchantInfo) bool {
+	return info != nil && info.Active == MerchantStatusActive
+}
+
+// Balance 返回商户余额
+func (m *MerchantInfo) Balance() (Money, error) {
+	return ParseAmount(m.Money)
+}
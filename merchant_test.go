@@ -0,0 +1,41 @@
+package epay
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestClient_QueryMerchant(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("act"); got != "query" {
+			t.Errorf("act = %s, want query", got)
+		}
+		w.Write([]byte(`{"code":1,"pid":1001,"active":1,"money":"128.50","type":1,"account":"pay@example.com","username":"张三","orders":30,"order_today":15,"order_lastday":15}`))
+	})
+
+	info, err := client.QueryMerchant()
+	if err != nil {
+		t.Fatalf("QueryMerchant() error = %v", err)
+	}
+
+	if !IsMerchantActive(info) {
+		t.Error("IsMerchantActive() = false, want true")
+	}
+	balance, err := info.Balance()
+	if err != nil || balance != 12850 {
+		t.Errorf("Balance() = %d, %v, want 12850", balance, err)
+	}
+	if info.OrderToday != 15 {
+		t.Errorf("OrderToday = %d, want 15", info.OrderToday)
+	}
+}
+
+func TestClient_QueryMerchant_APIError(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"code":-1,"msg":"KEY校验失败"}`))
+	})
+
+	if _, err := client.QueryMerchant(); err == nil {
+		t.Error("QueryMerchant() should return error for code != 1")
+	}
+}
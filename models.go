@@ -1,5 +1,10 @@
 package epay
 
+import (
+	"strconv"
+	"time"
+)
+
 // PaymentRequest API 接口支付请求
 type PaymentRequest struct {
 	Type       string  // 支付方式: alipay, wxpay, qqpay 等
@@ -80,6 +85,9 @@ type NotifyData struct {
 	Param       string // 业务扩展参数
 	Sign        string // 签名字符串
 	SignType    string // 签名类型
+
+	// RefundRequired 订单已通过 CloseOrder 取消，但仍收到支付成功通知，需要退款
+	RefundRequired bool
 }
 
 // OrderQueryRequest 订单查询请求
@@ -98,20 +106,37 @@ func (r *OrderQueryRequest) Validate() error {
 
 // OrderDetail 订单详情
 type OrderDetail struct {
-	Code       int    `json:"code"`
-	Msg        string `json:"msg"`
-	TradeNo    string `json:"trade_no"`
-	OutTradeNo string `json:"out_trade_no"`
-	APITradeNo string `json:"api_trade_no"`
-	Type       string `json:"type"`
-	PID        int    `json:"pid"`
-	AddTime    string `json:"addtime"`
-	EndTime    string `json:"endtime"`
-	Name       string `json:"name"`
-	Money      string `json:"money"`
-	Status     int    `json:"status"` // 1=已支付, 0=未支付
-	Param      string `json:"param"`
-	Buyer      string `json:"buyer"`
+	Code       int         `json:"code"`
+	Msg        string      `json:"msg"`
+	TradeNo    string      `json:"trade_no"`
+	OutTradeNo string      `json:"out_trade_no"`
+	APITradeNo string      `json:"api_trade_no"`
+	Type       string      `json:"type"`
+	PID        int         `json:"pid"`
+	AddTime    string      `json:"addtime"`
+	EndTime    string      `json:"endtime"`
+	Name       string      `json:"name"`
+	Money      string      `json:"money"`
+	Status     OrderStatus `json:"status"` // 1=已支付, 0=未支付（此前为 int，见 CHANGELOG.md）
+	Param      string      `json:"param"`
+	Buyer      string      `json:"buyer"`
+}
+
+// OrderInfo 订单详情的类型化视图
+// 时间按网关时区解析，金额为精确的 Money
+type OrderInfo struct {
+	TradeNo    string      // EPay订单号
+	OutTradeNo string      // 商户订单号
+	APITradeNo string      // 第三方支付订单号
+	Type       string      // 支付方式
+	PID        int         // 商户ID
+	AddTime    time.Time   // 创建时间
+	EndTime    time.Time   // 完成时间（未完成时为零值）
+	Name       string      // 商品名称
+	Money      Money       // 商品金额
+	Status     OrderStatus // 订单状态
+	Param      string      // 业务扩展参数
+	Buyer      string      // 支付者账号
 }
 
 // OrderListResponse 订单列表响应
@@ -122,11 +147,59 @@ type OrderListResponse struct {
 	Orders []OrderDetail `json:"orders"`
 }
 
+// SettlementRecord 结算记录
+type SettlementRecord struct {
+	ID        int    `json:"id"`        // 结算记录ID
+	Type      int    `json:"type"`      // 结算方式: 1=支付宝, 2=微信, 3=QQ钱包, 4=银行卡
+	Account   string `json:"account"`   // 结算账号
+	Username  string `json:"username"`  // 结算账户姓名
+	Money     string `json:"money"`     // 结算金额
+	RealMoney string `json:"realmoney"` // 实际到账金额
+	AddTime   string `json:"addtime"`   // 结算时间
+	EndTime   string `json:"endtime"`   // 完成时间
+	Status    int    `json:"status"`    // 结算状态: 0=待结算, 1=已完成, 2=结算中, 3=结算失败
+}
+
+// SettlementListResponse 结算记录列表响应
+type SettlementListResponse struct {
+	Code int                `json:"code"`
+	Msg  string             `json:"msg"`
+	Data []SettlementRecord `json:"data"`
+}
+
+// CloseOrderRequest 关闭订单请求
+type CloseOrderRequest struct {
+	OutTradeNo string // 商户订单号
+	TradeNo    string // EPay订单号（可选）
+}
+
+// Validate 验证关闭订单请求参数
+func (r *CloseOrderRequest) Validate() error {
+	if r.OutTradeNo == "" {
+		return ErrMissingOutTradeNo
+	}
+	return nil
+}
+
+// CloseOrderResponse 关闭订单响应
+type CloseOrderResponse struct {
+	Code int    `json:"code"` // 1=成功
+	Msg  string `json:"msg"`
+}
+
+// CloseOrderResult 关闭订单结果
+type CloseOrderResult struct {
+	OutTradeNo    string // 商户订单号
+	GatewayClosed bool   // 网关是否已关闭订单
+	GatewayError  error  // 网关关闭失败的原因（订单已回退为本地取消）
+}
+
 // RefundRequest 退款请求
 type RefundRequest struct {
-	TradeNo    string  // EPay订单号（二选一）
-	OutTradeNo string  // 商户订单号（二选一）
-	Money      float64 // 退款金额
+	TradeNo     string  // EPay订单号（二选一）
+	OutTradeNo  string  // 商户订单号（二选一）
+	Money       float64 // 退款金额
+	OutRefundNo string  // 商户退款单号（可选，用于后续查询退款状态）
 }
 
 // Validate 验证退款请求参数
@@ -142,19 +215,135 @@ func (r *RefundRequest) Validate() error {
 
 // RefundResponse 退款响应
 type RefundResponse struct {
-	Code int    `json:"code"` // 1=成功
-	Msg  string `json:"msg"`
+	Code        int    `json:"code"` // 1=成功
+	Msg         string `json:"msg"`
+	RefundNo    string `json:"refund_no"`     // EPay退款单号（部分服务端返回）
+	OutRefundNo string `json:"out_refund_no"` // 商户退款单号（部分服务端返回）
+	Money       string `json:"money"`         // 本次退款金额（部分服务端返回）
+}
+
+// RefundQueryRequest 退款查询请求
+type RefundQueryRequest struct {
+	RefundNo    string // EPay退款单号（二选一）
+	OutRefundNo string // 商户退款单号（二选一）
+}
+
+// Validate 验证退款查询请求参数
+func (r *RefundQueryRequest) Validate() error {
+	if r.RefundNo == "" && r.OutRefundNo == "" {
+		return ErrMissingRefundNo
+	}
+	return nil
+}
+
+// RefundDetail 退款详情
+type RefundDetail struct {
+	Code        int    `json:"code"`
+	Msg         string `json:"msg"`
+	RefundNo    string `json:"refund_no"`     // EPay退款单号
+	OutRefundNo string `json:"out_refund_no"` // 商户退款单号
+	TradeNo     string `json:"trade_no"`      // EPay订单号
+	OutTradeNo  string `json:"out_trade_no"`  // 商户订单号
+	Money       string `json:"money"`         // 退款金额
+	Status      int    `json:"status"`        // 0=处理中, 1=退款成功, 2=退款失败
+	AddTime     string `json:"addtime"`       // 申请时间
+	EndTime     string `json:"endtime"`       // 完成时间
+}
+
+// RefundListResponse 订单退款记录响应
+type RefundListResponse struct {
+	Code        int            `json:"code"`
+	Msg         string         `json:"msg"`
+	TradeNo     string         `json:"trade_no"`     // EPay订单号
+	OutTradeNo  string         `json:"out_trade_no"` // 商户订单号
+	Money       string         `json:"money"`        // 订单金额
+	RefundMoney string         `json:"refundmoney"`  // 已退款总金额
+	Refunds     []RefundDetail `json:"data"`         // 退款记录
+}
+
+// MerchantInfo 商户信息
+type MerchantInfo struct {
+	Code         int    `json:"code"`
+	Msg          string `json:"msg"`
+	PID          int    `json:"pid"`           // 商户ID
+	Active       int    `json:"active"`        // 商户状态: 1=正常, 0=封禁
+	Money        string `json:"money"`         // 商户余额
+	SettleType   int    `json:"type"`          // 结算方式: 1=支付宝, 2=微信, 3=QQ钱包, 4=银行卡
+	Account      string `json:"account"`       // 结算账号
+	Username     string `json:"username"`      // 结算账户姓名
+	Rate         string `json:"rate"`          // 费率（百分比，部分服务端返回）
+	Orders       int    `json:"orders"`        // 订单总数
+	OrderToday   int    `json:"order_today"`   // 今日订单数
+	OrderLastday int    `json:"order_lastday"` // 昨日订单数
 }
 
 // 支付状态常量
 const (
 	TradeStatusSuccess = "TRADE_SUCCESS" // 支付成功
+	// TradeStatusRefund 已退款
+	// 非标准 EPay 协议状态，仅部分衍生版本在退款后发送，标准网关只会发送 TRADE_SUCCESS
+	TradeStatusRefund = "TRADE_REFUND"
+)
+
+// OrderStatus 订单状态
+type OrderStatus int
+
+// 订单状态常量（与常见 EPay 服务端的 status 字段取值一致）
+const (
+	OrderStatusUnpaid   OrderStatus = 0 // 未支付
+	OrderStatusPaid     OrderStatus = 1 // 已支付
+	OrderStatusRefunded OrderStatus = 2 // 已退款
+	OrderStatusFrozen   OrderStatus = 3 // 已冻结
+)
+
+// String 返回订单状态的可读名称
+func (s OrderStatus) String() string {
+	switch s {
+	case OrderStatusUnpaid:
+		return "unpaid"
+	case OrderStatusPaid:
+		return "paid"
+	case OrderStatusRefunded:
+		return "refunded"
+	case OrderStatusFrozen:
+		return "frozen"
+	default:
+		return "unknown(" + strconv.Itoa(int(s)) + ")"
+	}
+}
+
+// IsKnown 检查是否为已知的订单状态
+func (s OrderStatus) IsKnown() bool {
+	return s >= OrderStatusUnpaid && s <= OrderStatusFrozen
+}
+
+// 商户状态常量
+const (
+	MerchantStatusBanned = 0 // 已封禁
+	MerchantStatusActive = 1 // 正常
+)
+
+// 结算方式常量
+const (
+	SettleTypeAlipay = 1 // 支付宝
+	SettleTypeWxpay  = 2 // 微信
+	SettleTypeQQpay  = 3 // QQ钱包
+	SettleTypeBank   = 4 // 银行卡
+)
+
+// 结算状态常量
+const (
+	SettleStatusPending    = 0 // 待结算
+	SettleStatusDone       = 1 // 已完成
+	SettleStatusProcessing = 2 // 结算中
+	SettleStatusFailed     = 3 // 结算失败
 )
 
-// 订单状态常量
+// 退款状态常量
 const (
-	OrderStatusUnpaid = 0 // 未支付
-	OrderStatusPaid   = 1 // 已支付
+	RefundStatusProcessing = 0 // 处理中
+	RefundStatusSuccess    = 1 // 退款成功
+	RefundStatusFailed     = 2 // 退款失败
 )
 
 // 支付方式常量
@@ -0,0 +1,105 @@
+package epay
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Money 精确金额，单位为分
+// 避免使用 float64 表示金额带来的精度问题
+type Money int64
+
+// ParseAmount 将金额字符串（如 "10.00"、"0.5"、"3"）精确解析为 Money
+// 最多支持两位有效小数，多余的小数位必须为 0
+func ParseAmount(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, NewError(ErrCodeInvalidParam, "empty money string")
+	}
+
+	negative := false
+	if s[0] == '-' || s[0] == '+' {
+		negative = s[0] == '-'
+		s = s[1:]
+	}
+
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	if intPart == "" && fracPart == "" {
+		return 0, NewError(ErrCodeInvalidParam, "invalid money string")
+	}
+	if intPart == "" {
+		intPart = "0"
+	}
+
+	// 超出两位的小数必须全为 0，否则无法精确表示
+	if len(fracPart) > 2 {
+		if strings.Trim(fracPart[2:], "0") != "" {
+			return 0, NewError(ErrCodeInvalidParam, fmt.Sprintf("money %q has more than 2 decimal places", s))
+		}
+		fracPart = fracPart[:2]
+	}
+	for len(fracPart) < 2 {
+		fracPart += "0"
+	}
+
+	yuan, err := strconv.ParseInt(intPart, 10, 64)
+	if err != nil || !isDigits(intPart) {
+		return 0, WrapError(ErrCodeInvalidParam, fmt.Sprintf("invalid money string %q", s), err)
+	}
+	fen, err := strconv.ParseInt(fracPart, 10, 64)
+	if err != nil || !isDigits(fracPart) {
+		return 0, WrapError(ErrCodeInvalidParam, fmt.Sprintf("invalid money string %q", s), err)
+	}
+
+	// 超出 int64 分的范围时拒绝，避免溢出回绕
+	if yuan > (math.MaxInt64-fen)/100 {
+		return 0, NewError(ErrCodeInvalidParam, fmt.Sprintf("money %q out of range", s))
+	}
+
+	m := Money(yuan*100 + fen)
+	if negative {
+		m = -m
+	}
+	return m, nil
+}
+
+// MoneyFromFloat 将以元为单位的 float64 金额四舍五入为 Money
+func MoneyFromFloat(yuan float64) Money {
+	if yuan < 0 {
+		return Money(yuan*100 - 0.5)
+	}
+	return Money(yuan*100 + 0.5)
+}
+
+// Fen 返回以分为单位的金额
+func (m Money) Fen() int64 {
+	return int64(m)
+}
+
+// Float64 返回以元为单位的金额
+func (m Money) Float64() float64 {
+	return float64(m) / 100
+}
+
+// String 格式化为保留两位小数的字符串，与 FormatMoney 保持一致
+func (m Money) String() string {
+	sign := ""
+	v := int64(m)
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, v/100, v%100)
+}
+
+// isDigits 检查字符串是否只包含数字
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,114 @@
+package epay
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+// 回调校验不匹配的字段
+const (
+	MismatchFieldPID        = "pid"          // 商户ID不匹配
+	MismatchFieldOutTradeNo = "out_trade_no" // 商户订单号不存在
+	MismatchFieldMoney      = "money"        // 金额不匹配
+	MismatchFieldStatus     = "status"       // 订单状态不允许支付
+)
+
+// ErrNotifyMismatch 回调与预期订单不匹配（用于 errors.Is 判断）
+var ErrNotifyMismatch = NewError(ErrCodeVerifyFailed, "notify does not match expected order")
+
+// ExpectedOrder 商户侧记录的预期订单
+type ExpectedOrder struct {
+	OutTradeNo string      // 商户订单号
+	Money      Money       // 下单金额
+	Status     OrderStatus // 本地订单状态
+}
+
+// ExpectedOrderResolver 按商户订单号加载预期订单
+// 订单不存在时返回 (nil, nil)
+type ExpectedOrderResolver func(ctx context.Context, outTradeNo string) (*ExpectedOrder, error)
+
+// NotifyMismatchError 回调与预期订单不匹配错误
+// 签名正确但内容不匹配，通常意味着密钥泄露或回调被伪造，应作为安全告警处理
+type NotifyMismatchError struct {
+	Field      string // 不匹配的字段（MismatchField*）
+	Expected   string // 预期值
+	Actual     string // 回调中的值
+	OutTradeNo string // 商户订单号
+	TradeNo    string // EPay订单号
+}
+
+// Error 实现 error 接口
+func (e *NotifyMismatchError) Error() string {
+	return fmt.Sprintf("notify mismatch for order %s (trade_no=%s): %s expected %q, got %q",
+		e.OutTradeNo, e.TradeNo, e.Field, e.Expected, e.Actual)
+}
+
+// Is 支持 errors.Is(err, ErrNotifyMismatch)
+func (e *NotifyMismatchError) Is(target error) bool {
+	return target == ErrNotifyMismatch
+}
+
+// VerifyNotifyOrder 验证回调签名，并校验回调与预期订单是否一致
+// 不一致时返回 *NotifyMismatchError
+func (c *Client) VerifyNotifyOrder(ctx context.Context, params map[string]string, resolve ExpectedOrderResolver) (*NotifyData, error) {
+	notifyData, err := c.VerifyNotify(params)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.CheckNotifyOrder(ctx, notifyData, resolve); err != nil {
+		return nil, err
+	}
+
+	return notifyData, nil
+}
+
+// CheckNotifyOrder 校验已验签的回调与预期订单是否一致
+// 依次检查 pid 是否为本商户、订单是否存在、订单状态是否允许支付（仅支付成功通知）、金额是否一致
+// （退款通知只要求金额不超过下单金额）
+func (c *Client) CheckNotifyOrder(ctx context.Context, data *NotifyData, resolve ExpectedOrderResolver) error {
+	mismatch := func(field, expected, actual string) error {
+		return &NotifyMismatchError{
+			Field:      field,
+			Expected:   expected,
+			Actual:     actual,
+			OutTradeNo: data.OutTradeNo,
+			TradeNo:    data.TradeNo,
+		}
+	}
+
+	// 检查商户ID
+	if data.PID != c.config.PID {
+		return mismatch(MismatchFieldPID, strconv.Itoa(c.config.PID), strconv.Itoa(data.PID))
+	}
+
+	// 加载预期订单
+	expected, err := resolve(ctx, data.OutTradeNo)
+	if err != nil {
+		return WrapError(ErrCodeStoreError, "resolve expected order failed", err)
+	}
+	if expected == nil {
+		return mismatch(MismatchFieldOutTradeNo, "existing order", data.OutTradeNo)
+	}
+
+	// 已退款或冻结的订单不应再收到支付成功通知
+	if data.TradeStatus == TradeStatusSuccess &&
+		expected.Status != OrderStatusUnpaid && expected.Status != OrderStatusPaid {
+		return mismatch(MismatchFieldStatus, "unpaid or paid", expected.Status.String())
+	}
+
+	// 检查金额：退款通知可能是部分退款，金额不超过下单金额即可
+	money, err := ParseAmount(data.Money)
+	if data.TradeStatus == TradeStatusRefund {
+		if err != nil || money <= 0 || money > expected.Money {
+			return mismatch(MismatchFieldMoney, "<= "+expected.Money.String(), data.Money)
+		}
+		return nil
+	}
+	if err != nil || money != expected.Money {
+		return mismatch(MismatchFieldMoney, expected.Money.String(), data.Money)
+	}
+
+	return nil
+}
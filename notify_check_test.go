@@ -0,0 +1,92 @@
+package epay
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestClient_VerifyNotifyOrder(t *testing.T) {
+	client, _ := NewClient(&Config{PID: 1001, Key: "testkey123", APIBaseURL: "https://pay.example.com"})
+
+	orders := map[string]*ExpectedOrder{
+		"ORDER001": {OutTradeNo: "ORDER001", Money: 1000, Status: OrderStatusUnpaid},
+		"ORDER002": {OutTradeNo: "ORDER002", Money: 1000, Status: OrderStatusRefunded},
+	}
+	resolve := func(ctx context.Context, outTradeNo string) (*ExpectedOrder, error) {
+		return orders[outTradeNo], nil
+	}
+
+	tests := []struct {
+		name        string
+		pid         string
+		outTradeNo  string
+		money       string
+		tradeStatus string
+		wantField   string
+	}{
+		{"match", "1001", "ORDER001", "10.00", TradeStatusSuccess, ""},
+		{"match without trailing zeros", "1001", "ORDER001", "10", TradeStatusSuccess, ""},
+		{"other merchant", "1002", "ORDER001", "10.00", TradeStatusSuccess, MismatchFieldPID},
+		{"unknown order", "1001", "ORDER999", "10.00", TradeStatusSuccess, MismatchFieldOutTradeNo},
+		{"refunded order", "1001", "ORDER002", "10.00", TradeStatusSuccess, MismatchFieldStatus},
+		// 订单状态只约束支付成功通知，已退款订单的退款通知可以通过
+		{"refund notify for refunded order", "1001", "ORDER002", "10.00", TradeStatusRefund, ""},
+		{"partial refund notify", "1001", "ORDER002", "3.00", TradeStatusRefund, ""},
+		{"refund notify exceeds amount", "1001", "ORDER002", "10.01", TradeStatusRefund, MismatchFieldMoney},
+		{"refund notify zero amount", "1001", "ORDER002", "0", TradeStatusRefund, MismatchFieldMoney},
+		{"wrong amount", "1001", "ORDER001", "0.01", TradeStatusSuccess, MismatchFieldMoney},
+		{"invalid amount", "1001", "ORDER001", "abc", TradeStatusSuccess, MismatchFieldMoney},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := map[string]string{
+				"pid":          tt.pid,
+				"trade_no":     "2024010112345678",
+				"out_trade_no": tt.outTradeNo,
+				"type":         "alipay",
+				"name":         "VIP会员",
+				"money":        tt.money,
+				"trade_status": tt.tradeStatus,
+				"sign_type":    "MD5",
+			}
+			params["sign"] = client.Sign(params)
+
+			data, err := client.VerifyNotifyOrder(context.Background(), params, resolve)
+			if tt.wantField == "" {
+				if err != nil {
+					t.Fatalf("VerifyNotifyOrder() error = %v", err)
+				}
+				if data.OutTradeNo != tt.outTradeNo {
+					t.Errorf("OutTradeNo = %s, want %s", data.OutTradeNo, tt.outTradeNo)
+				}
+				return
+			}
+
+			var mismatch *NotifyMismatchError
+			if !errors.As(err, &mismatch) {
+				t.Fatalf("VerifyNotifyOrder() error = %v, want *NotifyMismatchError", err)
+			}
+			if mismatch.Field != tt.wantField {
+				t.Errorf("Field = %s, want %s", mismatch.Field, tt.wantField)
+			}
+			if !errors.Is(err, ErrNotifyMismatch) {
+				t.Error("errors.Is(err, ErrNotifyMismatch) = false")
+			}
+		})
+	}
+}
+
+func TestClient_CheckNotifyOrder_ResolverError(t *testing.T) {
+	client, _ := NewClient(&Config{PID: 1001, Key: "testkey123", APIBaseURL: "https://pay.example.com"})
+
+	boom := errors.New("db down")
+	err := client.CheckNotifyOrder(context.Background(), &NotifyData{PID: 1001, OutTradeNo: "ORDER001"},
+		func(ctx context.Context, outTradeNo string) (*ExpectedOrder, error) {
+			return nil, boom
+		})
+	if !errors.Is(err, boom) || errors.Is(err, ErrNotifyMismatch) {
+		t.Errorf("CheckNotifyOrder() error = %v, want wrapped resolver error", err)
+	}
+}
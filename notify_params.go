@@ -0,0 +1,170 @@
+package epay
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+)
+
+// DefaultMaxNotifyBodySize 回调请求体默认最大字节数
+const DefaultMaxNotifyBodySize = 1 << 20
+
+// ParseNotifyParams 从 HTTP 请求中解析回调参数
+// 支持 URL 查询参数，以及 application/x-www-form-urlencoded、multipart/form-data、
+// application/json 请求体（最大 DefaultMaxNotifyBodySize 字节）。
+// 同一参数出现多次且取值不同时返回错误，而不是任选其一。
+func ParseNotifyParams(r *http.Request) (map[string]string, error) {
+	return ParseNotifyParamsLimit(r, DefaultMaxNotifyBodySize)
+}
+
+// ParseNotifyParamsLimit 与 ParseNotifyParams 相同，使用指定的请求体大小上限
+func ParseNotifyParamsLimit(r *http.Request, maxBodySize int64) (map[string]string, error) {
+	params := make(map[string]string)
+
+	// 解析 URL 查询参数
+	query, err := url.ParseQuery(r.URL.RawQuery)
+	if err != nil {
+		return nil, WrapError(ErrCodeInvalidParam, "parse notify query failed", err)
+	}
+	if err := mergeValues(params, query); err != nil {
+		return nil, err
+	}
+
+	// 解析请求体
+	if r.Body == nil || r.Body == http.NoBody || r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return params, nil
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, maxBodySize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, NewError(ErrCodeInvalidParam, fmt.Sprintf("notify body exceeds %d bytes", maxBodySize))
+		}
+		return nil, WrapError(ErrCodeInvalidParam, "read notify body failed", err)
+	}
+	if len(body) == 0 {
+		return params, nil
+	}
+
+	mediaType, mediaParams, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil && r.Header.Get("Content-Type") != "" {
+		return nil, WrapError(ErrCodeInvalidParam, "invalid notify content type", err)
+	}
+
+	var bodyParams url.Values
+	switch mediaType {
+	case "", "application/x-www-form-urlencoded":
+		bodyParams, err = url.ParseQuery(string(body))
+		if err != nil {
+			return nil, WrapError(ErrCodeInvalidParam, "parse notify form failed", err)
+		}
+	case "multipart/form-data":
+		bodyParams, err = parseMultipartParams(body, mediaParams["boundary"], maxBodySize)
+		if err != nil {
+			return nil, err
+		}
+	case "application/json":
+		bodyParams, err = parseJSONParams(body)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, NewError(ErrCodeInvalidParam, fmt.Sprintf("unsupported notify content type %q", mediaType))
+	}
+
+	if err := mergeValues(params, bodyParams); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+// mergeValues 合并参数，同一参数取值不一致时返回错误
+func mergeValues(params map[string]string, values url.Values) error {
+	for key, vals := range values {
+		for _, v := range vals {
+			if existing, ok := params[key]; ok && existing != v {
+				return NewError(ErrCodeInvalidParam, fmt.Sprintf("ambiguous duplicate notify parameter %q", key))
+			}
+			params[key] = v
+		}
+	}
+	return nil
+}
+
+// parseMultipartParams 解析 multipart/form-data 请求体中的普通字段（忽略文件）
+func parseMultipartParams(body []byte, boundary string, maxBodySize int64) (url.Values, error) {
+	if boundary == "" {
+		return nil, NewError(ErrCodeInvalidParam, "missing multipart boundary")
+	}
+
+	form, err := multipart.NewReader(bytes.NewReader(body), boundary).ReadForm(maxBodySize)
+	if err != nil {
+		return nil, WrapError(ErrCodeInvalidParam, "parse notify multipart failed", err)
+	}
+	defer form.RemoveAll()
+
+	return url.Values(form.Value), nil
+}
+
+// parseJSONParams 解析 JSON 对象请求体
+// 只支持一层对象，值为字符串、数字、布尔或 null；重复的键按取值是否一致处理
+func parseJSONParams(body []byte) (url.Values, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+
+	invalid := func(err error) error {
+		return WrapError(ErrCodeInvalidParam, "parse notify JSON failed", err)
+	}
+
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, invalid(err)
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return nil, NewError(ErrCodeInvalidParam, "notify JSON body must be an object")
+	}
+
+	values := url.Values{}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, invalid(err)
+		}
+		key := tok.(string)
+
+		tok, err = dec.Token()
+		if err != nil {
+			return nil, invalid(err)
+		}
+		var value string
+		switch v := tok.(type) {
+		case string:
+			value = v
+		case json.Number:
+			value = v.String()
+		case bool:
+			value = fmt.Sprint(v)
+		case nil:
+			value = ""
+		default:
+			return nil, NewError(ErrCodeInvalidParam, fmt.Sprintf("notify JSON field %q must be a scalar", key))
+		}
+		values[key] = append(values[key], value)
+	}
+
+	// 消费结尾的 '}'，并确保没有多余内容
+	if _, err := dec.Token(); err != nil {
+		return nil, invalid(err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, NewError(ErrCodeInvalidParam, "unexpected data after notify JSON object")
+	}
+	return values, nil
+}
@@ -1,15 +1,26 @@
 package epay
 
-import "strconv"
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+)
 
 // API 接口路径
 const (
 	APIPathQuery  = "/api.php" // 订单查询接口
 	APIPathOrders = "/api.php" // 批量订单查询接口
+	APIPathSettle = "/api.php" // 结算记录查询接口
 )
 
 // QueryOrder 查询单个订单
 func (c *Client) QueryOrder(req *OrderQueryRequest) (*OrderDetail, error) {
+	return c.QueryOrderContext(context.Background(), req)
+}
+
+// QueryOrderContext 查询单个订单（支持 context）
+func (c *Client) QueryOrderContext(ctx context.Context, req *OrderQueryRequest) (*OrderDetail, error) {
 	// 验证参数
 	if err := req.Validate(); err != nil {
 		return nil, err
@@ -27,7 +38,7 @@ func (c *Client) QueryOrder(req *OrderQueryRequest) (*OrderDetail, error) {
 	}
 
 	// 发送请求
-	body, err := c.doGet(APIPathQuery, params)
+	body, err := c.doGetContext(ctx, APIPathQuery, params)
 	if err != nil {
 		return nil, err
 	}
@@ -48,6 +59,11 @@ func (c *Client) QueryOrder(req *OrderQueryRequest) (*OrderDetail, error) {
 
 // QueryOrders 批量查询订单
 func (c *Client) QueryOrders(limit, page int) (*OrderListResponse, error) {
+	return c.QueryOrdersContext(context.Background(), limit, page)
+}
+
+// QueryOrdersContext 批量查询订单（支持 context）
+func (c *Client) QueryOrdersContext(ctx context.Context, limit, page int) (*OrderListResponse, error) {
 	// 参数校验
 	if limit <= 0 {
 		limit = 10
@@ -66,7 +82,7 @@ func (c *Client) QueryOrders(limit, page int) (*OrderListResponse, error) {
 	params["page"] = strconv.Itoa(page)
 
 	// 发送请求
-	body, err := c.doGet(APIPathOrders, params)
+	body, err := c.doGetContext(ctx, APIPathOrders, params)
 	if err != nil {
 		return nil, err
 	}
@@ -85,6 +101,63 @@ func (c *Client) QueryOrders(limit, page int) (*OrderListResponse, error) {
 	return resp, nil
 }
 
+// QuerySettlements 查询结算记录
+func (c *Client) QuerySettlements(limit, page int) (*SettlementListResponse, error) {
+	// 参数校验
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	if page <= 0 {
+		page = 1
+	}
+
+	// 构建请求参数
+	params := c.buildBaseParams()
+	params["act"] = "settle"
+	params["limit"] = strconv.Itoa(limit)
+	params["page"] = strconv.Itoa(page)
+
+	// 发送请求
+	body, err := c.doGet(APIPathSettle, params)
+	if err != nil {
+		return nil, err
+	}
+
+	// 解析响应
+	resp, err := parseJSONResponse[SettlementListResponse](body)
+	if err != nil {
+		return nil, err
+	}
+
+	// 检查业务错误
+	if resp.Code != 1 {
+		return nil, NewError(ErrCodeAPIError, resp.Msg)
+	}
+
+	return resp, nil
+}
+
+// Fee 返回结算手续费（结算金额 - 实际到账金额）
+func (s *SettlementRecord) Fee() (Money, error) {
+	money, err := ParseAmount(s.Money)
+	if err != nil {
+		return 0, err
+	}
+	realMoney, err := ParseAmount(s.RealMoney)
+	if err != nil {
+		return 0, err
+	}
+	return money - realMoney, nil
+}
+
+// IsSettlementDone 检查结算是否已完成
+func IsSettlementDone(record *SettlementRecord) bool {
+	return record != nil && record.Status == SettleStatusDone
+}
+
 // IsOrderPaid 检查订单是否已支付
 func IsOrderPaid(order *OrderDetail) bool {
 	return order != nil && order.Status == OrderStatusPaid
@@ -94,3 +167,61 @@ func IsOrderPaid(order *OrderDetail) bool {
 func IsOrderUnpaid(order *OrderDetail) bool {
 	return order != nil && order.Status == OrderStatusUnpaid
 }
+
+// IsOrderRefunded 检查订单是否已退款
+func IsOrderRefunded(order *OrderDetail) bool {
+	return order != nil && order.Status == OrderStatusRefunded
+}
+
+// 订单时间格式
+const orderTimeLayout = "2006-01-02 15:04:05"
+
+// Info 将订单详情转换为类型化视图
+// loc 为网关时区，为 nil 时使用默认时区
+func (o *OrderDetail) Info(loc *time.Location) (*OrderInfo, error) {
+	if loc == nil {
+		loc = (&Config{}).GetLocation()
+	}
+
+	addTime, err := parseOrderTime(o.AddTime, loc)
+	if err != nil {
+		return nil, WrapError(ErrCodeInvalidResponse, "parse addtime failed", err)
+	}
+	endTime, err := parseOrderTime(o.EndTime, loc)
+	if err != nil {
+		return nil, WrapError(ErrCodeInvalidResponse, "parse endtime failed", err)
+	}
+	money, err := ParseAmount(o.Money)
+	if err != nil {
+		return nil, WrapError(ErrCodeInvalidResponse, "parse money failed", err)
+	}
+
+	return &OrderInfo{
+		TradeNo:    o.TradeNo,
+		OutTradeNo: o.OutTradeNo,
+		APITradeNo: o.APITradeNo,
+		Type:       o.Type,
+		PID:        o.PID,
+		AddTime:    addTime,
+		EndTime:    endTime,
+		Name:       o.Name,
+		Money:      money,
+		Status:     o.Status,
+		Param:      o.Param,
+		Buyer:      o.Buyer,
+	}, nil
+}
+
+// OrderInfo 按客户端配置的网关时区将订单详情转换为类型化视图
+func (c *Client) OrderInfo(order *OrderDetail) (*OrderInfo, error) {
+	return order.Info(c.location)
+}
+
+// parseOrderTime 解析网关返回的订单时间
+// 空值及 "0000-00-00 00:00:00" 视为零值
+func parseOrderTime(s string, loc *time.Location) (time.Time, error) {
+	if s == "" || strings.HasPrefix(s, "0000-00-00") {
+		return time.Time{}, nil
+	}
+	return time.ParseInLocation(orderTimeLayout, s, loc)
+}
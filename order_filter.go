@@ -0,0 +1,174 @@
+package epay
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+)
+
+// errStopFilter 内部用于提前结束遍历
+var errStopFilter = errors.New("stop filter")
+
+// OrderFilter 订单过滤条件（客户端过滤，作用于 QueryOrders 分页列表）
+// 使用示例:
+//
+//	filter := epay.NewOrderFilter().
+//	    Status(epay.OrderStatusPaid).
+//	    PayType(epay.PayTypeWxpay).
+//	    Between(start, end).
+//	    MoneyRange(epay.MoneyFromFloat(100), 0)
+//	orders, err := client.SearchOrders(ctx, filter)
+type OrderFilter struct {
+	statuses    []OrderStatus
+	payTypes    []string
+	start       time.Time
+	end         time.Time
+	minMoney    Money
+	maxMoney    Money
+	nameKeyword string
+	paramPrefix string
+	pageSize    int
+}
+
+// NewOrderFilter 创建订单过滤条件（默认不过滤）
+func NewOrderFilter() *OrderFilter {
+	return &OrderFilter{}
+}
+
+// Clone 复制过滤条件，修改副本不影响原过滤条件
+func (f *OrderFilter) Clone() *OrderFilter {
+	c := *f
+	c.statuses = slices.Clone(f.statuses)
+	c.payTypes = slices.Clone(f.payTypes)
+	return &c
+}
+
+// Status 只保留指定状态的订单
+func (f *OrderFilter) Status(statuses ...OrderStatus) *OrderFilter {
+	f.statuses = append(f.statuses, statuses...)
+	return f
+}
+
+// PayType 只保留指定支付方式的订单
+func (f *OrderFilter) PayType(types ...string) *OrderFilter {
+	f.payTypes = append(f.payTypes, types...)
+	return f
+}
+
+// Between 只保留创建时间在 [start, end) 内的订单，零值表示不限制
+func (f *OrderFilter) Between(start, end time.Time) *OrderFilter {
+	f.start = start
+	f.end = end
+	return f
+}
+
+// MoneyRange 只保留金额在 [min, max] 内的订单，max <= 0 表示不限制上限
+func (f *OrderFilter) MoneyRange(min, max Money) *OrderFilter {
+	f.minMoney = min
+	f.maxMoney = max
+	return f
+}
+
+// NameContains 只保留商品名称包含 keyword 的订单
+func (f *OrderFilter) NameContains(keyword string) *OrderFilter {
+	f.nameKeyword = keyword
+	return f
+}
+
+// ParamPrefix 只保留业务扩展参数以 prefix 开头的订单
+func (f *OrderFilter) ParamPrefix(prefix string) *OrderFilter {
+	f.paramPrefix = prefix
+	return f
+}
+
+// PageSize 设置分页拉取时的每页数量（1~100，默认 100）
+func (f *OrderFilter) PageSize(size int) *OrderFilter {
+	f.pageSize = size
+	return f
+}
+
+// Match 检查订单是否满足过滤条件
+func (f *OrderFilter) Match(info *OrderInfo) bool {
+	if len(f.statuses) > 0 && !slices.Contains(f.statuses, info.Status) {
+		return false
+	}
+	if len(f.payTypes) > 0 && !slices.Contains(f.payTypes, info.Type) {
+		return false
+	}
+	if !f.start.IsZero() && info.AddTime.Before(f.start) {
+		return false
+	}
+	if !f.end.IsZero() && !info.AddTime.Before(f.end) {
+		return false
+	}
+	if info.Money < f.minMoney {
+		return false
+	}
+	if f.maxMoney > 0 && info.Money > f.maxMoney {
+		return false
+	}
+	if f.nameKeyword != "" && !strings.Contains(info.Name, f.nameKeyword) {
+		return false
+	}
+	if f.paramPrefix != "" && !strings.HasPrefix(info.Param, f.paramPrefix) {
+		return false
+	}
+	return true
+}
+
+// beforeWindow 检查订单是否早于时间窗口
+func (f *OrderFilter) beforeWindow(info *OrderInfo) bool {
+	return !f.start.IsZero() && !info.AddTime.IsZero() && info.AddTime.Before(f.start)
+}
+
+// FilterOrders 遍历满足条件的订单
+// 订单列表按创建时间倒序返回，遇到早于时间窗口的订单即停止拉取。
+// fn 返回 error 时停止遍历并返回该 error。
+func (c *Client) FilterOrders(ctx context.Context, filter *OrderFilter, fn func(order *OrderDetail, info *OrderInfo) error) error {
+	if filter == nil {
+		filter = NewOrderFilter()
+	}
+
+	it := c.IterateOrders(filter.pageSize)
+	for it.Next(ctx) {
+		order := it.Order()
+		info, err := c.OrderInfo(order)
+		if err != nil {
+			return err
+		}
+
+		// 已早于时间窗口，后续订单只会更早
+		if filter.beforeWindow(info) {
+			it.Stop()
+			break
+		}
+
+		if !filter.Match(info) {
+			continue
+		}
+		if err := fn(order, info); err != nil {
+			if errors.Is(err, errStopFilter) {
+				return nil
+			}
+			return err
+		}
+	}
+
+	return it.Err()
+}
+
+// SearchOrders 返回满足条件的全部订单
+// limit > 0 时最多返回 limit 个订单
+func (c *Client) SearchOrders(ctx context.Context, filter *OrderFilter, limit int) ([]*OrderInfo, error) {
+	var orders []*OrderInfo
+	err := c.FilterOrders(ctx, filter, func(_ *OrderDetail, info *OrderInfo) error {
+		orders = append(orders, info)
+		if limit > 0 && len(orders) >= limit {
+			return errStopFilter
+		}
+		return nil
+	})
+	return orders, err
+}
@@ -0,0 +1,73 @@
+package epay
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_SearchOrders(t *testing.T) {
+	// 40 个订单，按创建时间倒序，每小时一个
+	base := time.Date(2024, 1, 10, 12, 0, 0, 0, time.FixedZone("CST", 8*3600))
+	var pages int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&pages, 1)
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+
+		var orders []string
+		for i := (page - 1) * limit; i < page*limit && i < 40; i++ {
+			payType := PayTypeAlipay
+			if i%2 == 0 {
+				payType = PayTypeWxpay
+			}
+			addTime := base.Add(-time.Duration(i) * time.Hour).Format("2006-01-02 15:04:05")
+			orders = append(orders, fmt.Sprintf(`{"out_trade_no":"ORDER%02d","type":"%s","name":"VIP会员","money":"%d.00","addtime":"%s","status":%d,"param":"user:%d"}`,
+				i, payType, 50+i*10, addTime, i%3%2, i))
+		}
+		fmt.Fprintf(w, `{"code":1,"orders":[%s]}`, strings.Join(orders, ","))
+	})
+
+	filter := NewOrderFilter().
+		Status(OrderStatusPaid).
+		PayType(PayTypeWxpay).
+		Between(base.Add(-20*time.Hour), base.Add(time.Hour)).
+		MoneyRange(MoneyFromFloat(100), 0).
+		NameContains("VIP").
+		ParamPrefix("user:").
+		PageSize(10)
+
+	orders, err := client.SearchOrders(context.Background(), filter, 0)
+	if err != nil {
+		t.Fatalf("SearchOrders() error = %v", err)
+	}
+
+	var got []string
+	for _, order := range orders {
+		got = append(got, order.OutTradeNo)
+	}
+	// i%2==0（wxpay）、i%3==1（paid）、i>=5（金额 >= 100）、i<=20（时间窗口）
+	want := "ORDER10,ORDER16"
+	if strings.Join(got, ",") != want {
+		t.Errorf("SearchOrders() = %v, want %s", got, want)
+	}
+
+	// 第 3 页出现早于窗口的订单后停止，不再拉取第 4 页
+	if pages != 3 {
+		t.Errorf("fetched %d pages, want 3", pages)
+	}
+
+	atomic.StoreInt32(&pages, 0)
+	orders, err = client.SearchOrders(context.Background(), NewOrderFilter().PageSize(10), 5)
+	if err != nil || len(orders) != 5 {
+		t.Errorf("SearchOrders(limit=5) = %d orders, %v", len(orders), err)
+	}
+	if pages != 1 {
+		t.Errorf("fetched %d pages, want 1", pages)
+	}
+}
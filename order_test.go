@@ -0,0 +1,121 @@
+package epay
+
+import (
+	"math"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Money
+		wantErr bool
+	}{
+		{in: "10.00", want: 1000},
+		{in: "0.01", want: 1},
+		{in: "0.1", want: 10},
+		{in: "3", want: 300},
+		{in: "1.230", want: 123},
+		{in: "-2.50", want: -250},
+		{in: "1.234", wantErr: true},
+		{in: "abc", wantErr: true},
+		{in: "", wantErr: true},
+		{in: "1.2.3", wantErr: true},
+		{in: "92233720368547758.07", want: math.MaxInt64},
+		{in: "92233720368547758.08", wantErr: true},
+		{in: "100000000000000000", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseAmount(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseAmount(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ParseAmount(%q) = %d, want %d", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoney_String(t *testing.T) {
+	if got := Money(1234).String(); got != FormatMoney(12.34) {
+		t.Errorf("String() = %s, want %s", got, FormatMoney(12.34))
+	}
+	if got := Money(-5).String(); got != "-0.05" {
+		t.Errorf("String() = %s, want -0.05", got)
+	}
+	if got := MoneyFromFloat(19.99); got != 1999 {
+		t.Errorf("MoneyFromFloat(19.99) = %d, want 1999", got)
+	}
+}
+
+func TestOrderDetail_Info(t *testing.T) {
+	client, _ := NewClient(&Config{
+		PID:        1001,
+		Key:        "testkey123",
+		APIBaseURL: "https://pay.example.com",
+	})
+
+	detail := &OrderDetail{
+		TradeNo:    "T001",
+		OutTradeNo: "ORDER001",
+		AddTime:    "2024-01-02 10:00:00",
+		EndTime:    "",
+		Money:      "99.90",
+		Status:     OrderStatusRefunded,
+	}
+
+	info, err := client.OrderInfo(detail)
+	if err != nil {
+		t.Fatalf("OrderInfo() error = %v", err)
+	}
+
+	if info.Money != 9990 {
+		t.Errorf("Money = %d, want 9990", info.Money)
+	}
+	if !info.EndTime.IsZero() {
+		t.Errorf("EndTime = %v, want zero", info.EndTime)
+	}
+	want := time.Date(2024, 1, 2, 2, 0, 0, 0, time.UTC)
+	if !info.AddTime.Equal(want) {
+		t.Errorf("AddTime = %v, want %v", info.AddTime, want)
+	}
+	if info.Status.String() != "refunded" {
+		t.Errorf("Status = %s, want refunded", info.Status)
+	}
+
+	detail.AddTime = "not a time"
+	if _, err := client.OrderInfo(detail); err == nil {
+		t.Error("OrderInfo() should fail for invalid addtime")
+	}
+}
+
+func TestClient_QuerySettlements(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("act") != "settle" || q.Get("limit") != "100" || q.Get("page") != "2" {
+			t.Errorf("unexpected query: %s", r.URL.RawQuery)
+		}
+		w.Write([]byte(`{"code":1,"msg":"查询结算记录成功！","data":[{"id":7,"type":1,"account":"pay@example.com","username":"张三","money":"100.00","realmoney":"99.40","addtime":"2024-01-02 10:00:00","endtime":"2024-01-02 12:00:00","status":1}]}`))
+	})
+
+	resp, err := client.QuerySettlements(500, 2)
+	if err != nil {
+		t.Fatalf("QuerySettlements() error = %v", err)
+	}
+	if len(resp.Data) != 1 {
+		t.Fatalf("len(Data) = %d, want 1", len(resp.Data))
+	}
+
+	record := &resp.Data[0]
+	if !IsSettlementDone(record) {
+		t.Error("IsSettlementDone() = false, want true")
+	}
+	if fee, err := record.Fee(); err != nil || fee != 60 {
+		t.Errorf("Fee() = %d, %v, want 60", fee, err)
+	}
+}
@@ -0,0 +1,89 @@
+package epay
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// rateLimiter 请求速率限制器，按固定间隔放行请求
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time   // 下一个可用的放行时间
+	freed    []time.Time // 已取消的等待归还的放行时间（升序），优先分配给新请求
+}
+
+// newRateLimiter 创建每秒最多放行 rps 个请求的限制器，rps <= 0 时返回 nil（不限制）
+func newRateLimiter(rps float64) *rateLimiter {
+	if rps <= 0 {
+		return nil
+	}
+	return &rateLimiter{
+		interval: time.Duration(float64(time.Second) / rps),
+	}
+}
+
+// Wait 等待直到允许发送请求或 context 取消
+func (l *rateLimiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	at := l.reserve()
+
+	delay := time.Until(at)
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		l.release(at)
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// reserve 预约放行时间，优先复用已归还的时间
+func (l *rateLimiter) reserve() time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	for len(l.freed) > 0 && l.freed[0].Before(now) {
+		l.freed = l.freed[1:]
+	}
+	if len(l.freed) > 0 {
+		at := l.freed[0]
+		l.freed = l.freed[1:]
+		return at
+	}
+
+	at := l.next
+	if at.Before(now) {
+		at = now
+	}
+	l.next = at.Add(l.interval)
+	return at
+}
+
+// release 归还取消等待的放行时间，避免已取消的请求占用速率配额
+func (l *rateLimiter) release(at time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// 最后一个预约直接回退
+	if l.next.Equal(at.Add(l.interval)) {
+		l.next = at
+		return
+	}
+
+	// 之后已有其他预约，记录空出的时间供新请求使用
+	i, _ := slices.BinarySearchFunc(l.freed, at, func(a, b time.Time) int { return a.Compare(b) })
+	l.freed = slices.Insert(l.freed, i, at)
+}
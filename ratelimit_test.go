@@ -0,0 +1,50 @@
+package epay
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_ReleaseOnCancel(t *testing.T) {
+	l := newRateLimiter(10) // 100ms 一个请求
+
+	// 第一个请求立即放行
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+
+	// 排在后面的请求取消后归还预约
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for i := 0; i < 5; i++ {
+		if err := l.Wait(ctx); err != context.Canceled {
+			t.Fatalf("Wait() error = %v, want context.Canceled", err)
+		}
+	}
+
+	start := time.Now()
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Errorf("Wait() took %v after cancelled reservations, want about one interval", elapsed)
+	}
+}
+
+func TestRateLimiter_ReuseFreedSlot(t *testing.T) {
+	l := newRateLimiter(10)
+
+	first := l.reserve()
+	second := l.reserve()
+	third := l.reserve()
+
+	// 中间的预约取消后，新请求复用其时间，而不是排到最后
+	l.release(second)
+	if got := l.reserve(); !got.Equal(second) {
+		t.Errorf("reserve() = %v, want freed slot %v", got.Sub(first), second.Sub(first))
+	}
+	if got := l.reserve(); !got.Equal(third.Add(l.interval)) {
+		t.Errorf("reserve() = %v, want %v", got.Sub(first), third.Add(l.interval).Sub(first))
+	}
+}
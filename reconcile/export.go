@@ -0,0 +1,41 @@
+package reconcile
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+)
+
+// csvHeader CSV 导出的表头
+var csvHeader = []string{
+	"kind", "source", "out_trade_no", "trade_no",
+	"local_money", "remote_money", "local_status", "remote_status", "detail",
+}
+
+// WriteJSON 将对账报告以 JSON 格式写入 w
+func (r *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// WriteCSV 将对账差异以 CSV 格式写入 w（每条差异一行）
+func (r *Report) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, d := range r.Diffs {
+		record := []string{
+			string(d.Kind), d.Source, d.OutTradeNo, d.TradeNo,
+			d.LocalMoney, d.RemoteMoney, d.LocalStatus, d.RemoteStatus, d.Detail,
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,271 @@
+// Package reconcile 提供本地账本与 EPay 订单的对账能力
+// 对比本地订单与 QueryOrders 分页列表，生成类型化的差异报告，并支持导出为 JSON 和 CSV
+package reconcile
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	epay "github.com/liuscraft/epay-sdk-go"
+)
+
+// LocalOrder 本地账本中的订单
+type LocalOrder struct {
+	OutTradeNo string           // 商户订单号
+	TradeNo    string           // EPay订单号（可选）
+	Money      epay.Money       // 订单金额
+	Status     epay.OrderStatus // 订单状态
+}
+
+// LocalSource 本地订单来源
+type LocalSource interface {
+	// Orders 遍历本地订单，fn 返回 error 时停止遍历
+	Orders(ctx context.Context, fn func(order *LocalOrder) error) error
+}
+
+// RemoteSource 远端订单流，*epay.OrderIterator 实现了该接口
+type RemoteSource interface {
+	Next(ctx context.Context) bool
+	Order() *epay.OrderDetail
+	Err() error
+}
+
+// SliceSource 基于切片的本地订单来源
+type SliceSource []LocalOrder
+
+// Orders 遍历本地订单
+func (s SliceSource) Orders(ctx context.Context, fn func(order *LocalOrder) error) error {
+	for i := range s {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(&s[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DiffKind 差异类型
+type DiffKind string
+
+// 差异类型常量
+const (
+	DiffMissingLocal     DiffKind = "missing_local"      // 网关有、本地无
+	DiffMissingRemote    DiffKind = "missing_remote"     // 本地有、网关无
+	DiffStatusMismatch   DiffKind = "status_mismatch"    // 状态不一致
+	DiffAmountMismatch   DiffKind = "amount_mismatch"    // 金额不一致
+	DiffDuplicateTradeNo DiffKind = "duplicate_trade_no" // 订单号重复
+)
+
+// 差异来源常量
+const (
+	SourceLocal  = "local"  // 本地账本
+	SourceRemote = "remote" // EPay 网关
+)
+
+// Diff 单条对账差异
+type Diff struct {
+	Kind         DiffKind `json:"kind"`
+	Source       string   `json:"source,omitempty"` // 重复订单号所在的来源
+	OutTradeNo   string   `json:"out_trade_no"`
+	TradeNo      string   `json:"trade_no,omitempty"`
+	LocalMoney   string   `json:"local_money,omitempty"`
+	RemoteMoney  string   `json:"remote_money,omitempty"`
+	LocalStatus  string   `json:"local_status,omitempty"`
+	RemoteStatus string   `json:"remote_status,omitempty"`
+	Detail       string   `json:"detail,omitempty"`
+}
+
+// Report 对账报告
+type Report struct {
+	GeneratedAt  time.Time `json:"generated_at"`
+	LocalCount   int       `json:"local_count"`   // 本地订单数
+	RemoteCount  int       `json:"remote_count"`  // 网关订单数
+	MatchedCount int       `json:"matched_count"` // 完全一致的订单数
+	Diffs        []Diff    `json:"diffs"`
+}
+
+// HasDiffs 检查是否存在差异
+func (r *Report) HasDiffs() bool {
+	return len(r.Diffs) > 0
+}
+
+// Count 返回指定类型的差异数量
+func (r *Report) Count(kind DiffKind) int {
+	n := 0
+	for i := range r.Diffs {
+		if r.Diffs[i].Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+// Reconcile 对比本地账本与网关订单
+// 以商户订单号关联两侧订单；同一来源内重复的商户订单号或 EPay 订单号记为 DiffDuplicateTradeNo，
+// 重复项只有第一条参与比对。
+func Reconcile(ctx context.Context, local LocalSource, remote RemoteSource) (*Report, error) {
+	report := &Report{
+		GeneratedAt: time.Now(),
+		Diffs:       []Diff{},
+	}
+
+	// 加载本地订单
+	locals := make(map[string]*LocalOrder)
+	localTradeNos := make(map[string]string)
+	localDuplicates := make(map[string]bool) // trade_no 重复、未参与比对的本地商户订单号
+	err := local.Orders(ctx, func(order *LocalOrder) error {
+		report.LocalCount++
+		o := *order
+
+		if o.TradeNo != "" {
+			if first, ok := localTradeNos[o.TradeNo]; ok {
+				// 已在本地出现，对应的网关订单不应再报告为 missing_local
+				localDuplicates[o.OutTradeNo] = true
+				report.Diffs = append(report.Diffs, Diff{
+					Kind:       DiffDuplicateTradeNo,
+					Source:     SourceLocal,
+					OutTradeNo: o.OutTradeNo,
+					TradeNo:    o.TradeNo,
+					Detail:     fmt.Sprintf("trade_no already used by %s", first),
+				})
+				return nil
+			}
+			localTradeNos[o.TradeNo] = o.OutTradeNo
+		}
+
+		if _, ok := locals[o.OutTradeNo]; ok {
+			report.Diffs = append(report.Diffs, Diff{
+				Kind:       DiffDuplicateTradeNo,
+				Source:     SourceLocal,
+				OutTradeNo: o.OutTradeNo,
+				TradeNo:    o.TradeNo,
+				Detail:     "duplicate out_trade_no",
+			})
+			return nil
+		}
+		locals[o.OutTradeNo] = &o
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 遍历网关订单
+	seen := make(map[string]bool)
+	remoteTradeNos := make(map[string]string)
+	for remote.Next(ctx) {
+		order := remote.Order()
+		report.RemoteCount++
+
+		if order.TradeNo != "" {
+			if first, ok := remoteTradeNos[order.TradeNo]; ok {
+				// 已在网关出现，对应的本地订单不应再报告为 missing_remote
+				seen[order.OutTradeNo] = true
+				report.Diffs = append(report.Diffs, Diff{
+					Kind:        DiffDuplicateTradeNo,
+					Source:      SourceRemote,
+					OutTradeNo:  order.OutTradeNo,
+					TradeNo:     order.TradeNo,
+					RemoteMoney: order.Money,
+					Detail:      fmt.Sprintf("trade_no already used by %s", first),
+				})
+				continue
+			}
+			remoteTradeNos[order.TradeNo] = order.OutTradeNo
+		}
+
+		if seen[order.OutTradeNo] {
+			report.Diffs = append(report.Diffs, Diff{
+				Kind:        DiffDuplicateTradeNo,
+				Source:      SourceRemote,
+				OutTradeNo:  order.OutTradeNo,
+				TradeNo:     order.TradeNo,
+				RemoteMoney: order.Money,
+				Detail:      "duplicate out_trade_no",
+			})
+			continue
+		}
+		seen[order.OutTradeNo] = true
+
+		localOrder, ok := locals[order.OutTradeNo]
+		if !ok {
+			if localDuplicates[order.OutTradeNo] {
+				continue
+			}
+			report.Diffs = append(report.Diffs, Diff{
+				Kind:         DiffMissingLocal,
+				OutTradeNo:   order.OutTradeNo,
+				TradeNo:      order.TradeNo,
+				RemoteMoney:  order.Money,
+				RemoteStatus: order.Status.String(),
+			})
+			continue
+		}
+
+		if diffs := compare(localOrder, order); len(diffs) > 0 {
+			report.Diffs = append(report.Diffs, diffs...)
+		} else {
+			report.MatchedCount++
+		}
+	}
+	if err := remote.Err(); err != nil {
+		return nil, err
+	}
+
+	// 本地有、网关无
+	missing := make([]string, 0)
+	for outTradeNo := range locals {
+		if !seen[outTradeNo] {
+			missing = append(missing, outTradeNo)
+		}
+	}
+	sort.Strings(missing)
+	for _, outTradeNo := range missing {
+		o := locals[outTradeNo]
+		report.Diffs = append(report.Diffs, Diff{
+			Kind:        DiffMissingRemote,
+			OutTradeNo:  o.OutTradeNo,
+			TradeNo:     o.TradeNo,
+			LocalMoney:  o.Money.String(),
+			LocalStatus: o.Status.String(),
+		})
+	}
+
+	return report, nil
+}
+
+// compare 比对同一订单在本地与网关的金额和状态
+func compare(local *LocalOrder, remote *epay.OrderDetail) []Diff {
+	var diffs []Diff
+
+	remoteMoney, err := epay.ParseAmount(remote.Money)
+	if err != nil || remoteMoney != local.Money {
+		diff := Diff{
+			Kind:        DiffAmountMismatch,
+			OutTradeNo:  remote.OutTradeNo,
+			TradeNo:     remote.TradeNo,
+			LocalMoney:  local.Money.String(),
+			RemoteMoney: remote.Money,
+		}
+		if err != nil {
+			diff.Detail = err.Error()
+		}
+		diffs = append(diffs, diff)
+	}
+
+	if remote.Status != local.Status {
+		diffs = append(diffs, Diff{
+			Kind:         DiffStatusMismatch,
+			OutTradeNo:   remote.OutTradeNo,
+			TradeNo:      remote.TradeNo,
+			LocalStatus:  local.Status.String(),
+			RemoteStatus: remote.Status.String(),
+		})
+	}
+
+	return diffs
+}
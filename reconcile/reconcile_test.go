@@ -0,0 +1,130 @@
+package reconcile
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	epay "github.com/liuscraft/epay-sdk-go"
+)
+
+// sliceRemote 基于切片的网关订单流
+type sliceRemote struct {
+	orders []epay.OrderDetail
+	idx    int
+}
+
+func (s *sliceRemote) Next(ctx context.Context) bool {
+	if s.idx >= len(s.orders) {
+		return false
+	}
+	s.idx++
+	return true
+}
+
+func (s *sliceRemote) Order() *epay.OrderDetail { return &s.orders[s.idx-1] }
+
+func (s *sliceRemote) Err() error { return nil }
+
+func TestReconcile(t *testing.T) {
+	local := SliceSource{
+		{OutTradeNo: "A", TradeNo: "T1", Money: 1000, Status: epay.OrderStatusPaid},
+		{OutTradeNo: "B", TradeNo: "T2", Money: 500, Status: epay.OrderStatusPaid},
+		{OutTradeNo: "C", TradeNo: "T3", Money: 300, Status: epay.OrderStatusPaid},
+		{OutTradeNo: "D", Money: 100, Status: epay.OrderStatusUnpaid},
+		{OutTradeNo: "D", Money: 100, Status: epay.OrderStatusUnpaid},
+	}
+	remote := &sliceRemote{orders: []epay.OrderDetail{
+		{OutTradeNo: "A", TradeNo: "T1", Money: "10.00", Status: epay.OrderStatusPaid},
+		{OutTradeNo: "B", TradeNo: "T2", Money: "5.00", Status: epay.OrderStatusRefunded},
+		{OutTradeNo: "C", TradeNo: "T3", Money: "3.01", Status: epay.OrderStatusPaid},
+		{OutTradeNo: "E", TradeNo: "T5", Money: "1.00", Status: epay.OrderStatusPaid},
+		{OutTradeNo: "F", TradeNo: "T5", Money: "1.00", Status: epay.OrderStatusPaid},
+	}}
+
+	report, err := Reconcile(context.Background(), local, remote)
+	if err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	want := map[DiffKind]int{
+		DiffStatusMismatch:   1, // B
+		DiffAmountMismatch:   1, // C
+		DiffMissingLocal:     1, // E
+		DiffMissingRemote:    1, // D
+		DiffDuplicateTradeNo: 2, // 本地 D、网关 T5
+	}
+	for kind, n := range want {
+		if got := report.Count(kind); got != n {
+			t.Errorf("Count(%s) = %d, want %d", kind, got, n)
+		}
+	}
+	if report.MatchedCount != 1 || report.LocalCount != 5 || report.RemoteCount != 5 {
+		t.Errorf("counts = matched %d local %d remote %d, want 1/5/5",
+			report.MatchedCount, report.LocalCount, report.RemoteCount)
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+	var decoded Report
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || len(decoded.Diffs) != 6 {
+		t.Errorf("WriteJSON() produced %d diffs, err = %v", len(decoded.Diffs), err)
+	}
+
+	buf.Reset()
+	if err := report.WriteCSV(&buf); err != nil {
+		t.Fatalf("WriteCSV() error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 7 || !strings.HasPrefix(lines[0], "kind,source,out_trade_no") {
+		t.Errorf("WriteCSV() = %q", buf.String())
+	}
+}
+
+func TestReconcile_DuplicateRemoteTradeNo(t *testing.T) {
+	local := SliceSource{
+		{OutTradeNo: "A", TradeNo: "T1", Money: 100, Status: epay.OrderStatusPaid},
+		{OutTradeNo: "B", TradeNo: "T2", Money: 100, Status: epay.OrderStatusPaid},
+	}
+	remote := &sliceRemote{orders: []epay.OrderDetail{
+		{OutTradeNo: "A", TradeNo: "T1", Money: "1.00", Status: epay.OrderStatusPaid},
+		{OutTradeNo: "B", TradeNo: "T1", Money: "1.00", Status: epay.OrderStatusPaid},
+	}}
+
+	report, err := Reconcile(context.Background(), local, remote)
+	if err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if got := report.Count(DiffDuplicateTradeNo); got != 1 {
+		t.Errorf("Count(%s) = %d, want 1", DiffDuplicateTradeNo, got)
+	}
+	if got := report.Count(DiffMissingRemote); got != 0 {
+		t.Errorf("Count(%s) = %d, want 0: %+v", DiffMissingRemote, got, report.Diffs)
+	}
+}
+
+func TestReconcile_DuplicateLocalTradeNo(t *testing.T) {
+	local := SliceSource{
+		{OutTradeNo: "A", TradeNo: "T1", Money: 100, Status: epay.OrderStatusPaid},
+		{OutTradeNo: "B", TradeNo: "T1", Money: 100, Status: epay.OrderStatusPaid},
+	}
+	remote := &sliceRemote{orders: []epay.OrderDetail{
+		{OutTradeNo: "A", TradeNo: "T1", Money: "1.00", Status: epay.OrderStatusPaid},
+		{OutTradeNo: "B", TradeNo: "T2", Money: "1.00", Status: epay.OrderStatusPaid},
+	}}
+
+	report, err := Reconcile(context.Background(), local, remote)
+	if err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if got := report.Count(DiffDuplicateTradeNo); got != 1 {
+		t.Errorf("Count(%s) = %d, want 1", DiffDuplicateTradeNo, got)
+	}
+	if got := report.Count(DiffMissingLocal); got != 0 {
+		t.Errorf("Count(%s) = %d, want 0: %+v", DiffMissingLocal, got, report.Diffs)
+	}
+}
@@ -4,7 +4,8 @@ import "fmt"
 
 // API 接口路径
 const (
-	APIPathRefund = "/api.php" // 退款接口
+	APIPathRefund      = "/api.php" // 退款接口
+	APIPathRefundQuery = "/api.php" // 退款查询接口
 )
 
 // Refund 提交订单退款
@@ -26,6 +27,11 @@ func (c *Client) Refund(req *RefundRequest) (*RefundResponse, error) {
 		params["trade_no"] = req.TradeNo
 	}
 
+	// 可选参数
+	if req.OutRefundNo != "" {
+		params["out_refund_no"] = req.OutRefundNo
+	}
+
 	// 发送请求
 	body, err := c.doGet(APIPathRefund, params)
 	if err != nil {
@@ -46,6 +52,103 @@ func (c *Client) Refund(req *RefundRequest) (*RefundResponse, error) {
 	return resp, nil
 }
 
+// QueryRefund 按退款单号查询退款状态
+func (c *Client) QueryRefund(req *RefundQueryRequest) (*RefundDetail, error) {
+	// 验证参数
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	// 构建请求参数
+	params := c.buildBaseParams()
+	params["act"] = "refundquery"
+
+	// 优先使用商户退款单号
+	if req.OutRefundNo != "" {
+		params["out_refund_no"] = req.OutRefundNo
+	} else if req.RefundNo != "" {
+		params["refund_no"] = req.RefundNo
+	}
+
+	// 发送请求
+	body, err := c.doGet(APIPathRefundQuery, params)
+	if err != nil {
+		return nil, err
+	}
+
+	// 解析响应
+	resp, err := parseJSONResponse[RefundDetail](body)
+	if err != nil {
+		return nil, err
+	}
+
+	// 检查业务错误
+	if resp.Code != 1 {
+		return nil, NewError(ErrCodeAPIError, resp.Msg)
+	}
+
+	return resp, nil
+}
+
+// QueryOrderRefunds 查询订单的全部退款记录及已退款总金额
+func (c *Client) QueryOrderRefunds(req *OrderQueryRequest) (*RefundListResponse, error) {
+	// 验证参数
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	// 构建请求参数
+	params := c.buildBaseParams()
+	params["act"] = "refundquery"
+
+	// 优先使用商户订单号
+	if req.OutTradeNo != "" {
+		params["out_trade_no"] = req.OutTradeNo
+	} else if req.TradeNo != "" {
+		params["trade_no"] = req.TradeNo
+	}
+
+	// 发送请求
+	body, err := c.doGet(APIPathRefundQuery, params)
+	if err != nil {
+		return nil, err
+	}
+
+	// 解析响应
+	resp, err := parseJSONResponse[RefundListResponse](body)
+	if err != nil {
+		return nil, err
+	}
+
+	// 检查业务错误
+	if resp.Code != 1 {
+		return nil, NewError(ErrCodeAPIError, resp.Msg)
+	}
+
+	return resp, nil
+}
+
+// RefundedAmount 返回订单已退款总金额
+// 服务端未返回 refundmoney 时，按退款成功的记录累加
+func (r *RefundListResponse) RefundedAmount() (Money, error) {
+	if r.RefundMoney != "" {
+		return ParseAmount(r.RefundMoney)
+	}
+
+	var total Money
+	for i := range r.Refunds {
+		if r.Refunds[i].Status != RefundStatusSuccess {
+			continue
+		}
+		money, err := ParseAmount(r.Refunds[i].Money)
+		if err != nil {
+			return 0, err
+		}
+		total += money
+	}
+	return total, nil
+}
+
 // RefundByOutTradeNo 通过商户订单号退款（便捷方法）
 func (c *Client) RefundByOutTradeNo(outTradeNo string, money float64) (*RefundResponse, error) {
 	return c.Refund(&RefundRequest{
@@ -66,3 +169,8 @@ func (c *Client) RefundByTradeNo(tradeNo string, money float64) (*RefundResponse
 func IsRefundSuccess(resp *RefundResponse) bool {
 	return resp != nil && resp.Code == 1
 }
+
+// IsRefundCompleted 检查退款单是否已退款成功
+func IsRefundCompleted(detail *RefundDetail) bool {
+	return detail != nil && detail.Status == RefundStatusSuccess
+}
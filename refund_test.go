@@ -0,0 +1,89 @@
+package epay
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestClient_QueryRefund(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("act") != "refundquery" || q.Get("out_refund_no") != "R001" {
+			t.Errorf("unexpected query: %s", r.URL.RawQuery)
+		}
+		w.Write([]byte(`{"code":1,"refund_no":"RF123","out_refund_no":"R001","trade_no":"T001","out_trade_no":"ORDER001","money":"5.00","status":1}`))
+	})
+
+	detail, err := client.QueryRefund(&RefundQueryRequest{OutRefundNo: "R001"})
+	if err != nil {
+		t.Fatalf("QueryRefund() error = %v", err)
+	}
+	if !IsRefundCompleted(detail) {
+		t.Error("IsRefundCompleted() = false, want true")
+	}
+	if detail.RefundNo != "RF123" {
+		t.Errorf("RefundNo = %s, want RF123", detail.RefundNo)
+	}
+
+	if _, err := client.QueryRefund(&RefundQueryRequest{}); err != ErrMissingRefundNo {
+		t.Errorf("QueryRefund() error = %v, want ErrMissingRefundNo", err)
+	}
+}
+
+func TestClient_QueryOrderRefunds(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("act") != "refundquery" {
+			t.Errorf("unexpected query: %s", r.URL.RawQuery)
+		}
+		switch q.Get("out_trade_no") {
+		case "ORDER001":
+			w.Write([]byte(`{"code":1,"trade_no":"T001","out_trade_no":"ORDER001","money":"10.00","data":[` +
+				`{"refund_no":"RF1","out_refund_no":"R001","money":"3.00","status":1},` +
+				`{"refund_no":"RF2","out_refund_no":"R002","money":"2.00","status":2}]}`))
+		default:
+			w.Write([]byte(`{"code":-1,"msg":"订单不存在"}`))
+		}
+	})
+
+	resp, err := client.QueryOrderRefunds(&OrderQueryRequest{OutTradeNo: "ORDER001"})
+	if err != nil {
+		t.Fatalf("QueryOrderRefunds() error = %v", err)
+	}
+	if resp.TradeNo != "T001" || len(resp.Refunds) != 2 || resp.Refunds[1].OutRefundNo != "R002" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	if got, err := resp.RefundedAmount(); err != nil || got != 300 {
+		t.Errorf("RefundedAmount() = %d, %v, want 300", got, err)
+	}
+
+	_, err = client.QueryOrderRefunds(&OrderQueryRequest{OutTradeNo: "ORDER404"})
+	var epayErr *EPayError
+	if !errors.As(err, &epayErr) || epayErr.Code != ErrCodeAPIError || epayErr.Message != "订单不存在" {
+		t.Errorf("QueryOrderRefunds() error = %v, want API error", err)
+	}
+
+	if _, err := client.QueryOrderRefunds(&OrderQueryRequest{}); err == nil {
+		t.Error("QueryOrderRefunds() with empty request should fail")
+	}
+}
+
+func TestRefundListResponse_RefundedAmount(t *testing.T) {
+	resp := &RefundListResponse{
+		Refunds: []RefundDetail{
+			{Money: "5.00", Status: RefundStatusSuccess},
+			{Money: "2.50", Status: RefundStatusSuccess},
+			{Money: "1.00", Status: RefundStatusFailed},
+		},
+	}
+
+	if got, err := resp.RefundedAmount(); err != nil || got != 750 {
+		t.Errorf("RefundedAmount() = %d, %v, want 750", got, err)
+	}
+
+	resp.RefundMoney = "8.00"
+	if got, err := resp.RefundedAmount(); err != nil || got != 800 {
+		t.Errorf("RefundedAmount() = %d, %v, want 800", got, err)
+	}
+}
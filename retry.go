@@ -0,0 +1,50 @@
+package epay
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+)
+
+// 重试间隔上限
+const maxRetryInterval = 10 * time.Second
+
+// IsRetryable 检查错误是否为可重试的临时错误（网络错误）
+func IsRetryable(err error) bool {
+	var epayErr *EPayError
+	if errors.As(err, &epayErr) {
+		return epayErr.Code == ErrCodeNetworkError
+	}
+	return false
+}
+
+// withRetry 按客户端重试策略执行 fn
+// 仅对网络错误重试，间隔按指数退避，context 取消时立即返回
+func (c *Client) withRetry(ctx context.Context, fn func() error) error {
+	interval := c.config.GetRetryInterval()
+
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || !IsRetryable(err) || attempt >= c.config.MaxRetries {
+			return err
+		}
+
+		if c.config.Debug {
+			log.Printf("[EPay SDK] Retry %d/%d after %v: %v", attempt+1, c.config.MaxRetries, interval, err)
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		interval *= 2
+		if interval > maxRetryInterval {
+			interval = maxRetryInterval
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package tracker_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/liuscraft/epay-sdk-go/tracker"
+	"github.com/liuscraft/epay-sdk-go/tracker/storetest"
+)
+
+func TestMemoryStore(t *testing.T) {
+	storetest.Run(t, tracker.NewMemoryStore())
+}
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "orders.log")
+	store, err := tracker.OpenFileStore(path)
+	if err != nil {
+		t.Fatalf("OpenFileStore() error = %v", err)
+	}
+	storetest.Run(t, store)
+
+	if err := store.Compact(); err != nil {
+		t.Fatalf("Compact() error = %v", err)
+	}
+	store.Close()
+
+	// 重新打开后状态保持
+	reopened, err := tracker.OpenFileStore(path)
+	if err != nil {
+		t.Fatalf("OpenFileStore() error = %v", err)
+	}
+	defer reopened.Close()
+
+	order, err := reopened.Get(context.Background(), "B")
+	if err != nil || order.State != tracker.StatePaid || order.Version != 2 {
+		t.Errorf("Get(B) = %+v, %v", order, err)
+	}
+	if order, _ := reopened.Get(context.Background(), "C"); order.RefundedMoney != 20 {
+		t.Errorf("RefundedMoney = %d, want 20", order.RefundedMoney)
+	}
+}
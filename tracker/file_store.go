@@ -0,0 +1,163 @@
+package tracker
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+
+	"github.com/liuscraft/epay-sdk-go/internal/journal"
+)
+
+// FileStore 基于追加写日志文件的持久化订单存储
+// 每次写入都会将订单完整快照以 JSON 行追加到文件并 fsync，启动时按顺序重放日志恢复状态。
+// 日志行数超过存活订单数的若干倍时自动压缩（重写为每个订单一行）。
+// 崩溃时写了一半的最后一行会在打开时被截断。
+type FileStore struct {
+	mu      sync.Mutex
+	journal *journal.Journal
+	mem     *MemoryStore
+	logger  Logger
+}
+
+// Logger 日志接口
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// FileOption 文件订单存储配置选项
+type FileOption func(*FileStore)
+
+// WithFileLogger 设置日志器（默认 log.Default()），用于记录自动压缩失败等不影响写入结果的错误
+func WithFileLogger(logger Logger) FileOption {
+	return func(s *FileStore) {
+		s.logger = logger
+	}
+}
+
+// OpenFileStore 打开（或创建）文件订单存储
+func OpenFileStore(path string, opts ...FileOption) (*FileStore, error) {
+	s := &FileStore{
+		mem:    NewMemoryStore(),
+		logger: log.Default(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	j, err := journal.Open("order store", path, s.replay)
+	if err != nil {
+		return nil, err
+	}
+	s.journal = j
+
+	return s, nil
+}
+
+// Put 新增订单
+func (s *FileStore) Put(ctx context.Context, order *Order) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.mem.mu.Lock()
+	defer s.mem.mu.Unlock()
+	if _, ok := s.mem.orders[order.OutTradeNo]; ok {
+		return ErrOrderExists
+	}
+
+	next := *order
+	next.Version = 1
+	if err := s.journal.Append(&next); err != nil {
+		return err
+	}
+	s.mem.set(&next)
+	order.Version = next.Version
+	s.maybeCompact()
+	return nil
+}
+
+// Get 按商户订单号获取订单
+func (s *FileStore) Get(ctx context.Context, outTradeNo string) (*Order, error) {
+	return s.mem.Get(ctx, outTradeNo)
+}
+
+// GetByTradeNo 按 EPay 订单号获取订单
+func (s *FileStore) GetByTradeNo(ctx context.Context, tradeNo string) (*Order, error) {
+	return s.mem.GetByTradeNo(ctx, tradeNo)
+}
+
+// CompareAndSwap 按版本号条件写入订单
+func (s *FileStore) CompareAndSwap(ctx context.Context, order *Order) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.mem.mu.Lock()
+	defer s.mem.mu.Unlock()
+	current, ok := s.mem.orders[order.OutTradeNo]
+	if !ok {
+		return ErrOrderNotFound
+	}
+	if current.Version != order.Version {
+		return ErrVersionConflict
+	}
+
+	next := *order
+	next.Version++
+	if err := s.journal.Append(&next); err != nil {
+		return err
+	}
+	s.mem.set(&next)
+	order.Version = next.Version
+	s.maybeCompact()
+	return nil
+}
+
+// List 按过滤条件列出订单
+func (s *FileStore) List(ctx context.Context, filter ListFilter) ([]*Order, error) {
+	return s.mem.List(ctx, filter)
+}
+
+// Compact 压缩日志文件，只保留每个订单的最新快照
+func (s *FileStore) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.mem.mu.RLock()
+	defer s.mem.mu.RUnlock()
+	return s.journal.Rewrite(s.snapshot)
+}
+
+// Close 关闭存储
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.journal.Close()
+}
+
+// replay 重放一行订单快照
+func (s *FileStore) replay(line []byte) error {
+	var order Order
+	if err := json.Unmarshal(line, &order); err != nil {
+		return err
+	}
+	s.mem.set(&order)
+	return nil
+}
+
+// maybeCompact 日志冗余过多时压缩（调用方持有锁）
+// 写入已经持久化，压缩失败只记录日志
+func (s *FileStore) maybeCompact() {
+	if err := s.journal.MaybeCompact(len(s.mem.orders), s.snapshot); err != nil {
+		s.logger.Printf("Order store auto compaction failed: %v", err)
+	}
+}
+
+// snapshot 写出每个订单的最新快照（调用方持有锁）
+func (s *FileStore) snapshot(enc *json.Encoder) (int, error) {
+	for _, order := range s.mem.orders {
+		if err := enc.Encode(order); err != nil {
+			return 0, err
+		}
+	}
+	return len(s.mem.orders), nil
+}
@@ -0,0 +1,79 @@
+package sqlstore
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Dialect SQL 方言
+type Dialect interface {
+	// Name 方言名称
+	Name() string
+	// Placeholder 返回第 n 个（从 1 开始）参数占位符
+	Placeholder(n int) string
+	// InsertIgnore 将 INSERT INTO 语句改写为主键冲突时不报错的形式，冲突时影响行数必须为 0
+	// 不能先插入、失败后再查询：PostgreSQL 中失败的语句会中止整个事务，之后的语句都会失败
+	InsertIgnore(query string) string
+}
+
+// 内置方言
+var (
+	MySQL      Dialect = mysqlDialect{"mysql"}
+	SQLite     Dialect = questionDialect("sqlite")
+	PostgreSQL Dialect = postgresDialect{}
+)
+
+// questionDialect 使用 ? 作为占位符、支持 ON CONFLICT 的方言（SQLite）
+type questionDialect string
+
+func (d questionDialect) Name() string { return string(d) }
+
+func (d questionDialect) Placeholder(n int) string { return "?" }
+
+func (d questionDialect) InsertIgnore(query string) string { return query + " ON CONFLICT DO NOTHING" }
+
+// mysqlDialect MySQL 方言，使用 INSERT IGNORE
+// 不使用 ON DUPLICATE KEY UPDATE：DSN 开启 clientFoundRows 时重复记录也会报告影响 1 行。
+// 注意 INSERT IGNORE 同样会把其他错误（如严格模式关闭时的截断）降级为警告
+type mysqlDialect struct {
+	questionDialect
+}
+
+func (mysqlDialect) InsertIgnore(query string) string {
+	return strings.Replace(query, "INSERT INTO", "INSERT IGNORE INTO", 1)
+}
+
+// postgresDialect PostgreSQL 方言，占位符为 $1, $2 ...
+type postgresDialect struct{}
+
+func (postgresDialect) Name() string { return "postgres" }
+
+func (postgresDialect) Placeholder(n int) string { return "$" + strconv.Itoa(n) }
+
+func (postgresDialect) InsertIgnore(query string) string { return query + " ON CONFLICT DO NOTHING" }
+
+// rebind 将查询中的 ? 占位符替换为方言占位符（忽略单引号字符串中的 ?）
+func rebind(d Dialect, query string) string {
+	if d.Placeholder(1) == "?" {
+		return query
+	}
+
+	var b strings.Builder
+	b.Grow(len(query) + 8)
+	n := 0
+	inString := false
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '\'':
+			inString = !inString
+			b.WriteByte(c)
+		case c == '?' && !inString:
+			n++
+			b.WriteString(d.Placeholder(n))
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
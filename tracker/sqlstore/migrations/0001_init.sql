@@ -0,0 +1,43 @@
+-- 订单表
+CREATE TABLE epay_orders (
+    out_trade_no   VARCHAR(64)  NOT NULL PRIMARY KEY,
+    trade_no       VARCHAR(64)  NOT NULL DEFAULT '',
+    pay_type       VARCHAR(32)  NOT NULL DEFAULT '',
+    name           VARCHAR(255) NOT NULL DEFAULT '',
+    money          BIGINT       NOT NULL DEFAULT 0,
+    refunded_money BIGINT       NOT NULL DEFAULT 0,
+    param          VARCHAR(1024) NOT NULL DEFAULT '',
+    state          VARCHAR(32)  NOT NULL,
+    created_at     BIGINT       NOT NULL,
+    updated_at     BIGINT       NOT NULL,
+    paid_at        BIGINT       NOT NULL DEFAULT 0,
+    version        BIGINT       NOT NULL DEFAULT 1
+);
+
+CREATE INDEX idx_epay_orders_trade_no ON epay_orders (trade_no);
+
+CREATE INDEX idx_epay_orders_state_created ON epay_orders (state, created_at);
+
+-- 退款表
+CREATE TABLE epay_refunds (
+    out_refund_no VARCHAR(64) NOT NULL PRIMARY KEY,
+    out_trade_no  VARCHAR(64) NOT NULL,
+    refund_no     VARCHAR(64) NOT NULL DEFAULT '',
+    money         BIGINT      NOT NULL,
+    status        INTEGER     NOT NULL DEFAULT 0,
+    created_at    BIGINT      NOT NULL,
+    updated_at    BIGINT      NOT NULL
+);
+
+CREATE INDEX idx_epay_refunds_out_trade_no ON epay_refunds (out_trade_no);
+
+-- 回调通知记录表（同一 trade_no + trade_status 只记录一次）
+CREATE TABLE epay_notify_log (
+    trade_no     VARCHAR(64)  NOT NULL,
+    trade_status VARCHAR(32)  NOT NULL,
+    out_trade_no VARCHAR(64)  NOT NULL,
+    money        VARCHAR(32)  NOT NULL DEFAULT '',
+    params       TEXT         NOT NULL,
+    received_at  BIGINT       NOT NULL,
+    PRIMARY KEY (trade_no, trade_status)
+);
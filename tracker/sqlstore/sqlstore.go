@@ -0,0 +1,403 @@
+// Package sqlstore 提供基于 database/sql 的订单存储
+// 实现 tracker.OrderStore，并持久化退款记录与回调通知记录。
+// 内置版本化的建表迁移（embed），支持 MySQL、PostgreSQL、SQLite 占位符方言，
+// 并可在同一事务中写入通知记录与业务数据。
+//
+// 使用示例:
+//
+//	db, _ := sql.Open("mysql", dsn)
+//	store := sqlstore.New(db, sqlstore.MySQL)
+//	if err := store.Migrate(ctx); err != nil {
+//	    log.Fatal(err)
+//	}
+//	handlers := handler.NewHandlers(client, handler.WithOrderStore(store))
+//
+// 其他数据库可实现 Dialect（占位符与主键冲突忽略语法），
+// 并在接入真实数据库的集成测试中用 storetest.Run(t, store) 验证。
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	epay "github.com/liuscraft/epay-sdk-go"
+	"github.com/liuscraft/epay-sdk-go/tracker"
+)
+
+// orderColumns 订单表字段（与 scanOrder 顺序一致）
+const orderColumns = "out_trade_no, trade_no, pay_type, name, money, refunded_money, param, state, created_at, updated_at, paid_at, version"
+
+// Refund 退款记录
+type Refund struct {
+	OutRefundNo string     // 商户退款单号
+	OutTradeNo  string     // 商户订单号
+	RefundNo    string     // EPay退款单号
+	Money       epay.Money // 退款金额
+	Status      int        // 退款状态: 0=处理中, 1=成功, 2=失败
+	CreatedAt   time.Time  // 创建时间
+	UpdatedAt   time.Time  // 更新时间
+}
+
+// queryer *sql.DB 与 *sql.Tx 的公共方法
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// ops 订单、退款、通知记录的 SQL 操作
+type ops struct {
+	q       queryer
+	dialect Dialect
+}
+
+// Store 基于 database/sql 的订单存储，实现 tracker.OrderStore
+type Store struct {
+	ops
+	db *sql.DB
+}
+
+// Tx 事务内的订单存储，实现 tracker.OrderStore
+type Tx struct {
+	ops
+	tx *sql.Tx
+}
+
+// New 创建订单存储
+func New(db *sql.DB, dialect Dialect) *Store {
+	return &Store{
+		ops: ops{q: db, dialect: dialect},
+		db:  db,
+	}
+}
+
+// DB 返回底层数据库连接
+func (s *Store) DB() *sql.DB {
+	return s.db
+}
+
+// WithTx 在事务中执行 fn，fn 返回 error 时回滚，否则提交
+// 可用于让回调通知记录、订单状态与业务数据在同一事务中提交：
+//
+//	err := store.WithTx(ctx, func(tx *sqlstore.Tx) error {
+//	    first, err := tx.RecordNotify(ctx, data, params)
+//	    if err != nil || !first {
+//	        return err
+//	    }
+//	    _, err = tx.SQLTx().ExecContext(ctx, "UPDATE vip SET ...")
+//	    return err
+//	})
+func (s *Store) WithTx(ctx context.Context, fn func(tx *Tx) error) error {
+	sqlTx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+
+	tx := &Tx{
+		ops: ops{q: sqlTx, dialect: s.dialect},
+		tx:  sqlTx,
+	}
+	if err := fn(tx); err != nil {
+		if rbErr := sqlTx.Rollback(); rbErr != nil {
+			return fmt.Errorf("%w (rollback failed: %v)", err, rbErr)
+		}
+		return err
+	}
+
+	if err := sqlTx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+// SQLTx 返回底层事务，用于执行业务 SQL
+func (t *Tx) SQLTx() *sql.Tx {
+	return t.tx
+}
+
+// Put 新增订单
+func (o *ops) Put(ctx context.Context, order *tracker.Order) error {
+	inserted, err := o.insertIgnore(ctx,
+		"INSERT INTO epay_orders ("+orderColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		order.OutTradeNo, order.TradeNo, order.Type, order.Name, int64(order.Money), int64(order.RefundedMoney),
+		order.Param, string(order.State), toUnix(order.CreatedAt), toUnix(order.UpdatedAt), toUnix(order.PaidAt),
+		1,
+	)
+	if err != nil {
+		return fmt.Errorf("insert order: %w", err)
+	}
+	if !inserted {
+		return tracker.ErrOrderExists
+	}
+	order.Version = 1
+	return nil
+}
+
+// Get 按商户订单号获取订单
+func (o *ops) Get(ctx context.Context, outTradeNo string) (*tracker.Order, error) {
+	row := o.q.QueryRowContext(ctx, o.rebind(
+		"SELECT "+orderColumns+" FROM epay_orders WHERE out_trade_no = ?"), outTradeNo)
+	return scanOrder(row)
+}
+
+// GetByTradeNo 按 EPay 订单号获取订单
+func (o *ops) GetByTradeNo(ctx context.Context, tradeNo string) (*tracker.Order, error) {
+	if tradeNo == "" {
+		return nil, tracker.ErrOrderNotFound
+	}
+	row := o.q.QueryRowContext(ctx, o.rebind(
+		"SELECT "+orderColumns+" FROM epay_orders WHERE trade_no = ?"), tradeNo)
+	return scanOrder(row)
+}
+
+// CompareAndSwap 按版本号条件写入订单
+func (o *ops) CompareAndSwap(ctx context.Context, order *tracker.Order) error {
+	result, err := o.q.ExecContext(ctx, o.rebind(
+		`UPDATE epay_orders SET trade_no = ?, pay_type = ?, name = ?, money = ?, refunded_money = ?, param = ?,
+		state = ?, created_at = ?, updated_at = ?, paid_at = ?, version = version + 1
+		WHERE out_trade_no = ? AND version = ?`),
+		order.TradeNo, order.Type, order.Name, int64(order.Money), int64(order.RefundedMoney), order.Param,
+		string(order.State), toUnix(order.CreatedAt), toUnix(order.UpdatedAt), toUnix(order.PaidAt),
+		order.OutTradeNo, order.Version,
+	)
+	if err != nil {
+		return fmt.Errorf("update order: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("update order: %w", err)
+	}
+	if affected == 0 {
+		exists, err := o.orderExists(ctx, order.OutTradeNo)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return tracker.ErrOrderNotFound
+		}
+		return tracker.ErrVersionConflict
+	}
+
+	order.Version++
+	return nil
+}
+
+// List 按过滤条件列出订单
+func (o *ops) List(ctx context.Context, filter tracker.ListFilter) ([]*tracker.Order, error) {
+	var (
+		where []string
+		args  []any
+	)
+	if len(filter.States) > 0 {
+		marks := make([]string, len(filter.States))
+		for i, state := range filter.States {
+			marks[i] = "?"
+			args = append(args, string(state))
+		}
+		where = append(where, "state IN ("+strings.Join(marks, ", ")+")")
+	}
+	if !filter.CreatedAfter.IsZero() {
+		where = append(where, "created_at >= ?")
+		args = append(args, toUnix(filter.CreatedAfter))
+	}
+	if !filter.CreatedBefore.IsZero() {
+		where = append(where, "created_at < ?")
+		args = append(args, toUnix(filter.CreatedBefore))
+	}
+
+	query := "SELECT " + orderColumns + " FROM epay_orders"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY created_at, out_trade_no"
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", filter.Limit)
+	}
+
+	rows, err := o.q.QueryContext(ctx, o.rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("list orders: %w", err)
+	}
+	defer rows.Close()
+
+	var orders []*tracker.Order
+	for rows.Next() {
+		order, err := scanOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+	return orders, rows.Err()
+}
+
+// RecordNotify 记录回调通知，同一 trade_no + trade_status 只记录一次
+// 首次记录返回 true，重复通知返回 false
+func (o *ops) RecordNotify(ctx context.Context, data *epay.NotifyData, params map[string]string) (bool, error) {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return false, err
+	}
+
+	inserted, err := o.insertIgnore(ctx,
+		"INSERT INTO epay_notify_log (trade_no, trade_status, out_trade_no, money, params, received_at) VALUES (?, ?, ?, ?, ?, ?)",
+		data.TradeNo, data.TradeStatus, data.OutTradeNo, data.Money, string(raw), toUnix(time.Now()),
+	)
+	if err != nil {
+		return false, fmt.Errorf("insert notify log: %w", err)
+	}
+	return inserted, nil
+}
+
+// PutRefund 新增或更新退款记录
+func (o *ops) PutRefund(ctx context.Context, refund *Refund) error {
+	now := time.Now()
+	if refund.CreatedAt.IsZero() {
+		refund.CreatedAt = now
+	}
+	refund.UpdatedAt = now
+
+	inserted, err := o.insertIgnore(ctx,
+		"INSERT INTO epay_refunds (out_refund_no, out_trade_no, refund_no, money, status, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		refund.OutRefundNo, refund.OutTradeNo, refund.RefundNo, int64(refund.Money), refund.Status,
+		toUnix(refund.CreatedAt), toUnix(refund.UpdatedAt),
+	)
+	if err != nil {
+		return fmt.Errorf("insert refund: %w", err)
+	}
+	if inserted {
+		return nil
+	}
+
+	_, err = o.q.ExecContext(ctx, o.rebind(
+		"UPDATE epay_refunds SET refund_no = ?, money = ?, status = ?, updated_at = ? WHERE out_refund_no = ?"),
+		refund.RefundNo, int64(refund.Money), refund.Status, toUnix(refund.UpdatedAt), refund.OutRefundNo,
+	)
+	if err != nil {
+		return fmt.Errorf("update refund: %w", err)
+	}
+	return nil
+}
+
+// ListRefunds 列出订单的退款记录（按创建时间升序）
+func (o *ops) ListRefunds(ctx context.Context, outTradeNo string) ([]*Refund, error) {
+	rows, err := o.q.QueryContext(ctx, o.rebind(
+		`SELECT out_refund_no, out_trade_no, refund_no, money, status, created_at, updated_at
+		FROM epay_refunds WHERE out_trade_no = ? ORDER BY created_at, out_refund_no`), outTradeNo)
+	if err != nil {
+		return nil, fmt.Errorf("list refunds: %w", err)
+	}
+	defer rows.Close()
+
+	var refunds []*Refund
+	for rows.Next() {
+		var (
+			refund             Refund
+			money              int64
+			createdAt, updated int64
+		)
+		if err := rows.Scan(&refund.OutRefundNo, &refund.OutTradeNo, &refund.RefundNo, &money,
+			&refund.Status, &createdAt, &updated); err != nil {
+			return nil, fmt.Errorf("scan refund: %w", err)
+		}
+		refund.Money = epay.Money(money)
+		refund.CreatedAt = fromUnix(createdAt)
+		refund.UpdatedAt = fromUnix(updated)
+		refunds = append(refunds, &refund)
+	}
+	return refunds, rows.Err()
+}
+
+// insertIgnore 执行 INSERT，主键冲突时不报错，返回是否插入了新记录
+func (o *ops) insertIgnore(ctx context.Context, query string, args ...any) (bool, error) {
+	result, err := o.q.ExecContext(ctx, o.rebind(o.dialect.InsertIgnore(query)), args...)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// orderExists 检查订单是否存在
+func (o *ops) orderExists(ctx context.Context, outTradeNo string) (bool, error) {
+	return o.exists(ctx, "SELECT 1 FROM epay_orders WHERE out_trade_no = ?", outTradeNo)
+}
+
+// exists 检查查询是否返回记录
+func (o *ops) exists(ctx context.Context, query string, args ...any) (bool, error) {
+	var one int
+	err := o.q.QueryRowContext(ctx, o.rebind(query), args...).Scan(&one)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("query: %w", err)
+	}
+	return true, nil
+}
+
+// rebind 将 ? 占位符转换为方言占位符
+func (o *ops) rebind(query string) string {
+	return rebind(o.dialect, query)
+}
+
+// rowScanner *sql.Row 与 *sql.Rows 的公共方法
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanOrder 扫描一行订单记录
+func scanOrder(row rowScanner) (*tracker.Order, error) {
+	var (
+		order                        tracker.Order
+		money, refunded              int64
+		state                        string
+		createdAt, updatedAt, paidAt int64
+	)
+	err := row.Scan(&order.OutTradeNo, &order.TradeNo, &order.Type, &order.Name, &money, &refunded,
+		&order.Param, &state, &createdAt, &updatedAt, &paidAt, &order.Version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, tracker.ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("scan order: %w", err)
+	}
+
+	order.Money = epay.Money(money)
+	order.RefundedMoney = epay.Money(refunded)
+	order.State = tracker.State(state)
+	order.CreatedAt = fromUnix(createdAt)
+	order.UpdatedAt = fromUnix(updatedAt)
+	order.PaidAt = fromUnix(paidAt)
+	return &order, nil
+}
+
+// toUnix 将时间转换为 Unix 纳秒，零值为 0
+func toUnix(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+// fromUnix 将 Unix 纳秒转换为时间，0 为零值
+func fromUnix(n int64) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n)
+}
+
+var (
+	_ tracker.OrderStore = (*Store)(nil)
+	_ tracker.OrderStore = (*Tx)(nil)
+)
@@ -0,0 +1,98 @@
+package sqlstore
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRebind(t *testing.T) {
+	query := "SELECT * FROM t WHERE a = ? AND b = '?' AND c IN (?, ?)"
+
+	if got := rebind(MySQL, query); got != query {
+		t.Errorf("MySQL rebind = %q, want unchanged", got)
+	}
+
+	want := "SELECT * FROM t WHERE a = $1 AND b = '?' AND c IN ($2, $3)"
+	if got := rebind(PostgreSQL, query); got != want {
+		t.Errorf("PostgreSQL rebind = %q, want %q", got, want)
+	}
+}
+
+func TestDialect_InsertIgnore(t *testing.T) {
+	query := "INSERT INTO epay_notify_log (trade_no, trade_status) VALUES (?, ?)"
+
+	tests := []struct {
+		dialect Dialect
+		want    string
+	}{
+		// MySQL 使用 INSERT IGNORE，重复记录的影响行数不受 clientFoundRows 影响
+		{MySQL, "INSERT IGNORE INTO epay_notify_log (trade_no, trade_status) VALUES (?, ?)"},
+		{SQLite, "INSERT INTO epay_notify_log (trade_no, trade_status) VALUES (?, ?) ON CONFLICT DO NOTHING"},
+		{PostgreSQL, "INSERT INTO epay_notify_log (trade_no, trade_status) VALUES ($1, $2) ON CONFLICT DO NOTHING"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dialect.Name(), func(t *testing.T) {
+			if got := rebind(tt.dialect, tt.dialect.InsertIgnore(query)); got != tt.want {
+				t.Errorf("InsertIgnore() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMigrations(t *testing.T) {
+	migrations, err := Migrations()
+	if err != nil {
+		t.Fatalf("Migrations() error = %v", err)
+	}
+	if len(migrations) == 0 || migrations[0].Version != 1 {
+		t.Fatalf("Migrations() = %+v, want version 1 first", migrations)
+	}
+
+	for i, m := range migrations {
+		if i > 0 && m.Version <= migrations[i-1].Version {
+			t.Errorf("migration %s not in ascending order", m.Name)
+		}
+		for _, stmt := range m.Statements {
+			if strings.HasPrefix(stmt, "--") || strings.HasSuffix(stmt, ";") {
+				t.Errorf("migration %s has unclean statement %q", m.Name, stmt)
+			}
+		}
+	}
+
+	var tables []string
+	for _, stmt := range migrations[0].Statements {
+		if strings.HasPrefix(stmt, "CREATE TABLE") {
+			tables = append(tables, strings.Fields(stmt)[2])
+		}
+	}
+	for _, want := range []string{"epay_orders", "epay_refunds", "epay_notify_log"} {
+		found := false
+		for _, table := range tables {
+			if table == want {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("initial migration missing table %s (got %v)", want, tables)
+		}
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	content := "-- comment\nCREATE TABLE a (id INT);\n\n-- another\nCREATE INDEX i ON a (id);\n"
+	got := splitStatements(content)
+	if len(got) != 2 || got[0] != "CREATE TABLE a (id INT)" || got[1] != "CREATE INDEX i ON a (id)" {
+		t.Errorf("splitStatements() = %q", got)
+	}
+}
+
+func TestUnixRoundTrip(t *testing.T) {
+	if toUnix(time.Time{}) != 0 || !fromUnix(0).IsZero() {
+		t.Error("zero time should map to 0")
+	}
+	now := time.Now()
+	if got := fromUnix(toUnix(now)); !got.Equal(now) {
+		t.Errorf("fromUnix(toUnix(now)) = %v, want %v", got, now)
+	}
+}
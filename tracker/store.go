@@ -0,0 +1,188 @@
+package tracker
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrVersionConflict CompareAndSwap 时订单版本不匹配
+var ErrVersionConflict = errors.New("order version conflict")
+
+// OrderStore 订单存储
+// 所有实现都必须是并发安全的
+type OrderStore interface {
+	// Put 新增订单，订单已存在时返回 ErrOrderExists
+	Put(ctx context.Context, order *Order) error
+	// Get 按商户订单号获取订单，不存在时返回 ErrOrderNotFound
+	Get(ctx context.Context, outTradeNo string) (*Order, error)
+	// GetByTradeNo 按 EPay 订单号获取订单，不存在时返回 ErrOrderNotFound
+	GetByTradeNo(ctx context.Context, tradeNo string) (*Order, error)
+	// CompareAndSwap 仅当存储中的版本等于 order.Version 时写入订单，
+	// 写入成功后 order.Version 加 1；版本不匹配时返回 ErrVersionConflict
+	CompareAndSwap(ctx context.Context, order *Order) error
+	// List 按过滤条件列出订单，结果按创建时间升序排列
+	List(ctx context.Context, filter ListFilter) ([]*Order, error)
+}
+
+// ListFilter 订单列表过滤条件
+type ListFilter struct {
+	States        []State   // 只列出指定状态（为空表示不限制）
+	CreatedAfter  time.Time // 创建时间不早于该时间（零值表示不限制）
+	CreatedBefore time.Time // 创建时间早于该时间（零值表示不限制）
+	Limit         int       // 最多返回数量（<=0 表示不限制）
+}
+
+// Match 检查订单是否满足过滤条件
+func (f ListFilter) Match(order *Order) bool {
+	if len(f.States) > 0 && !slices.Contains(f.States, order.State) {
+		return false
+	}
+	if !f.CreatedAfter.IsZero() && order.CreatedAt.Before(f.CreatedAfter) {
+		return false
+	}
+	if !f.CreatedBefore.IsZero() && !order.CreatedAt.Before(f.CreatedBefore) {
+		return false
+	}
+	return true
+}
+
+// Update 读取订单并以 CompareAndSwap 写回 fn 修改后的结果，版本冲突时自动重试
+func Update(ctx context.Context, store OrderStore, outTradeNo string, fn func(order *Order) error) (*Order, error) {
+	for {
+		order, err := store.Get(ctx, outTradeNo)
+		if err != nil {
+			return nil, err
+		}
+		if err := fn(order); err != nil {
+			return nil, err
+		}
+
+		err = store.CompareAndSwap(ctx, order)
+		if errors.Is(err, ErrVersionConflict) {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return order, nil
+	}
+}
+
+// MemoryStore 基于内存的订单存储（并发安全，重启后丢失）
+type MemoryStore struct {
+	mu       sync.RWMutex
+	orders   map[string]*Order
+	tradeNos map[string]string // trade_no -> out_trade_no
+}
+
+// NewMemoryStore 创建内存订单存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		orders:   make(map[string]*Order),
+		tradeNos: make(map[string]string),
+	}
+}
+
+// Put 新增订单
+func (s *MemoryStore) Put(ctx context.Context, order *Order) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.put(order)
+}
+
+// Get 按商户订单号获取订单
+func (s *MemoryStore) Get(ctx context.Context, outTradeNo string) (*Order, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	order, ok := s.orders[outTradeNo]
+	if !ok {
+		return nil, ErrOrderNotFound
+	}
+	snapshot := *order
+	return &snapshot, nil
+}
+
+// GetByTradeNo 按 EPay 订单号获取订单
+func (s *MemoryStore) GetByTradeNo(ctx context.Context, tradeNo string) (*Order, error) {
+	s.mu.RLock()
+	outTradeNo, ok := s.tradeNos[tradeNo]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, ErrOrderNotFound
+	}
+	return s.Get(ctx, outTradeNo)
+}
+
+// CompareAndSwap 按版本号条件写入订单
+func (s *MemoryStore) CompareAndSwap(ctx context.Context, order *Order) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.compareAndSwap(order)
+}
+
+// List 按过滤条件列出订单
+func (s *MemoryStore) List(ctx context.Context, filter ListFilter) ([]*Order, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var orders []*Order
+	for _, order := range s.orders {
+		if filter.Match(order) {
+			snapshot := *order
+			orders = append(orders, &snapshot)
+		}
+	}
+
+	sort.Slice(orders, func(i, j int) bool {
+		if orders[i].CreatedAt.Equal(orders[j].CreatedAt) {
+			return orders[i].OutTradeNo < orders[j].OutTradeNo
+		}
+		return orders[i].CreatedAt.Before(orders[j].CreatedAt)
+	})
+
+	if filter.Limit > 0 && len(orders) > filter.Limit {
+		orders = orders[:filter.Limit]
+	}
+	return orders, nil
+}
+
+// put 新增订单（调用方持有写锁）
+func (s *MemoryStore) put(order *Order) error {
+	if _, ok := s.orders[order.OutTradeNo]; ok {
+		return ErrOrderExists
+	}
+	order.Version = 1
+	s.set(order)
+	return nil
+}
+
+// compareAndSwap 按版本号条件写入订单（调用方持有写锁）
+func (s *MemoryStore) compareAndSwap(order *Order) error {
+	current, ok := s.orders[order.OutTradeNo]
+	if !ok {
+		return ErrOrderNotFound
+	}
+	if current.Version != order.Version {
+		return ErrVersionConflict
+	}
+	order.Version++
+	s.set(order)
+	return nil
+}
+
+// set 保存订单副本并维护 trade_no 索引（调用方持有写锁）
+func (s *MemoryStore) set(order *Order) {
+	snapshot := *order
+	s.orders[order.OutTradeNo] = &snapshot
+	if order.TradeNo != "" {
+		s.tradeNos[order.TradeNo] = order.OutTradeNo
+	}
+}
@@ -0,0 +1,90 @@
+package tracker
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestFileStore_CompactEntries 压缩后日志只保留每个订单一行
+func TestFileStore_CompactEntries(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "orders.log")
+	store, err := OpenFileStore(path)
+	if err != nil {
+		t.Fatalf("OpenFileStore() error = %v", err)
+	}
+	for _, id := range []string{"A", "B", "C"} {
+		if err := store.Put(ctx, &Order{OutTradeNo: id, State: StateCreated}); err != nil {
+			t.Fatalf("Put(%s) error = %v", id, err)
+		}
+	}
+	if _, err := Update(ctx, store, "B", func(o *Order) error {
+		o.State = StatePaid
+		return nil
+	}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	if err := store.Compact(); err != nil {
+		t.Fatalf("Compact() error = %v", err)
+	}
+	store.Close()
+
+	reopened, err := OpenFileStore(path)
+	if err != nil {
+		t.Fatalf("OpenFileStore() error = %v", err)
+	}
+	defer reopened.Close()
+	if reopened.journal.Entries() != 3 {
+		t.Errorf("entries = %d, want 3 after compaction", reopened.journal.Entries())
+	}
+}
+
+func TestFileStore_TornTail(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "orders.log")
+	store, err := OpenFileStore(path)
+	if err != nil {
+		t.Fatalf("OpenFileStore() error = %v", err)
+	}
+	if err := store.Put(ctx, &Order{OutTradeNo: "A", Money: 100, State: StateCreated}); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	store.Close()
+
+	// 模拟追加写中途崩溃
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString(`{"out_trade_no":"B","mon`)
+	file.Close()
+
+	reopened, err := OpenFileStore(path)
+	if err != nil {
+		t.Fatalf("OpenFileStore() with torn tail error = %v", err)
+	}
+	if _, err := reopened.Get(ctx, "A"); err != nil {
+		t.Errorf("Get(A) error = %v", err)
+	}
+	if _, err := reopened.Get(ctx, "B"); err != ErrOrderNotFound {
+		t.Errorf("Get(B) error = %v, want ErrOrderNotFound", err)
+	}
+
+	// 截断后继续写入，再次打开不应损坏
+	if err := reopened.Put(ctx, &Order{OutTradeNo: "C", Money: 100, State: StateCreated}); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	reopened.Close()
+
+	again, err := OpenFileStore(path)
+	if err != nil {
+		t.Fatalf("OpenFileStore() error = %v", err)
+	}
+	defer again.Close()
+	if _, err := again.Get(ctx, "C"); err != nil {
+		t.Errorf("Get(C) error = %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+// Package storetest 提供 tracker.OrderStore 实现的一致性测试
+// 自定义订单存储可在测试中调用 Run 验证行为与内置实现一致：
+//
+//	func TestMyStore(t *testing.T) {
+//	    storetest.Run(t, newMyStore(t))
+//	}
+package storetest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/liuscraft/epay-sdk-go/tracker"
+)
+
+// Run 对空的订单存储执行一致性测试
+// 测试结束后存储中保留订单 A、B、C：B 为已支付（版本 2），C 的 RefundedMoney 为 20
+func Run(t *testing.T, store tracker.OrderStore) {
+	t.Helper()
+	ctx := context.Background()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i, id := range []string{"A", "B", "C"} {
+		order := &tracker.Order{OutTradeNo: id, TradeNo: "T" + id, Money: 100, State: tracker.StateCreated, CreatedAt: base.Add(time.Duration(i) * time.Hour)}
+		if err := store.Put(ctx, order); err != nil {
+			t.Fatalf("Put(%s) error = %v", id, err)
+		}
+		if order.Version != 1 {
+			t.Errorf("Put(%s) version = %d, want 1", id, order.Version)
+		}
+	}
+	if err := store.Put(ctx, &tracker.Order{OutTradeNo: "A"}); !errors.Is(err, tracker.ErrOrderExists) {
+		t.Errorf("Put(A) error = %v, want ErrOrderExists", err)
+	}
+
+	if _, err := store.Get(ctx, "missing"); !errors.Is(err, tracker.ErrOrderNotFound) {
+		t.Errorf("Get(missing) error = %v, want ErrOrderNotFound", err)
+	}
+	if _, err := store.GetByTradeNo(ctx, "Tmissing"); !errors.Is(err, tracker.ErrOrderNotFound) {
+		t.Errorf("GetByTradeNo(Tmissing) error = %v, want ErrOrderNotFound", err)
+	}
+
+	order, err := store.GetByTradeNo(ctx, "TB")
+	if err != nil || order.OutTradeNo != "B" {
+		t.Fatalf("GetByTradeNo(TB) = %v, %v", order, err)
+	}
+	if !order.CreatedAt.Equal(base.Add(time.Hour)) || order.Money != 100 || order.State != tracker.StateCreated {
+		t.Errorf("GetByTradeNo(TB) = %+v, fields not preserved", order)
+	}
+
+	// 过期版本写入失败
+	stale := *order
+	order.State = tracker.StatePaid
+	order.PaidAt = base.Add(2 * time.Hour)
+	if err := store.CompareAndSwap(ctx, order); err != nil {
+		t.Fatalf("CompareAndSwap() error = %v", err)
+	}
+	if order.Version != 2 {
+		t.Errorf("CompareAndSwap() version = %d, want 2", order.Version)
+	}
+	if err := store.CompareAndSwap(ctx, &stale); !errors.Is(err, tracker.ErrVersionConflict) {
+		t.Errorf("CompareAndSwap(stale) error = %v, want ErrVersionConflict", err)
+	}
+	if err := store.CompareAndSwap(ctx, &tracker.Order{OutTradeNo: "missing", Version: 1}); !errors.Is(err, tracker.ErrOrderNotFound) {
+		t.Errorf("CompareAndSwap(missing) error = %v, want ErrOrderNotFound", err)
+	}
+	if got, err := store.Get(ctx, "B"); err != nil || got.State != tracker.StatePaid || !got.PaidAt.Equal(order.PaidAt) {
+		t.Errorf("Get(B) = %+v, %v", got, err)
+	}
+
+	// 并发更新不丢失
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := tracker.Update(ctx, store, "C", func(o *tracker.Order) error {
+				o.RefundedMoney++
+				return nil
+			}); err != nil {
+				t.Errorf("Update() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if order, _ := store.Get(ctx, "C"); order.RefundedMoney != 20 {
+		t.Errorf("RefundedMoney = %d, want 20", order.RefundedMoney)
+	}
+
+	orders, err := store.List(ctx, tracker.ListFilter{States: []tracker.State{tracker.StateCreated}, CreatedBefore: base.Add(3 * time.Hour)})
+	if err != nil || len(orders) != 2 || orders[0].OutTradeNo != "A" || orders[1].OutTradeNo != "C" {
+		t.Errorf("List() = %v, %v", orders, err)
+	}
+	orders, err = store.List(ctx, tracker.ListFilter{CreatedAfter: base.Add(time.Hour), Limit: 1})
+	if err != nil || len(orders) != 1 || orders[0].OutTradeNo != "B" {
+		t.Errorf("List(after, limit 1) = %v, %v", orders, err)
+	}
+}
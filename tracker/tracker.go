@@ -0,0 +1,408 @@
+// Package tracker 提供订单生命周期状态机与订单跟踪器
+// 记录通过 CreatePayment/BuildFormPayment 创建的订单，并根据回调通知、订单查询和退款推进订单状态：
+//
+//	created → paid → partially_refunded → refunded
+//	created → expired / closed
+//
+// 非法的状态迁移会被拒绝，每次状态变化都会发出事件。
+package tracker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	epay "github.com/liuscraft/epay-sdk-go"
+)
+
+// State 订单生命周期状态
+type State string
+
+// 订单生命周期状态常量
+const (
+	StateCreated           State = "created"            // 已创建，待支付
+	StatePaid              State = "paid"               // 已支付
+	StatePartiallyRefunded State = "partially_refunded" // 部分退款
+	StateRefunded          State = "refunded"           // 全额退款
+	StateExpired           State = "expired"            // 已过期
+	StateClosed            State = "closed"             // 已关闭
+)
+
+// transitions 合法的状态迁移
+var transitions = map[State][]State{
+	StateCreated:           {StatePaid, StateExpired, StateClosed},
+	StatePaid:              {StatePartiallyRefunded, StateRefunded},
+	StatePartiallyRefunded: {StatePartiallyRefunded, StateRefunded},
+}
+
+// CanTransition 检查状态迁移是否合法
+func CanTransition(from, to State) bool {
+	for _, s := range transitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsFinal 检查是否为终态
+func (s State) IsFinal() bool {
+	return len(transitions[s]) == 0
+}
+
+// isPaid 检查订单是否已支付（包括已进入退款流程）
+func (s State) isPaid() bool {
+	return s == StatePaid || s == StatePartiallyRefunded || s == StateRefunded
+}
+
+// 事件来源常量
+const (
+	SourceCreate = "create" // 创建订单
+	SourceNotify = "notify" // 支付回调
+	SourceQuery  = "query"  // 订单查询
+	SourceRefund = "refund" // 退款
+	SourceExpire = "expire" // 过期
+	SourceClose  = "close"  // 关闭
+)
+
+// 预定义错误
+var (
+	ErrIllegalTransition = errors.New("illegal order state transition")
+	ErrOrderNotFound     = errors.New("order not found")
+	ErrOrderExists       = errors.New("order already tracked")
+	ErrRefundExceeded    = errors.New("refund amount exceeds remaining amount")
+)
+
+// TransitionError 非法状态迁移错误
+type TransitionError struct {
+	OutTradeNo string
+	From       State
+	To         State
+}
+
+// Error 实现 error 接口
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("order %s: illegal transition %s -> %s", e.OutTradeNo, e.From, e.To)
+}
+
+// Is 支持 errors.Is(err, ErrIllegalTransition)
+func (e *TransitionError) Is(target error) bool {
+	return target == ErrIllegalTransition
+}
+
+// Order 被跟踪的订单
+type Order struct {
+	OutTradeNo    string     `json:"out_trade_no"`   // 商户订单号
+	TradeNo       string     `json:"trade_no"`       // EPay订单号
+	Type          string     `json:"type"`           // 支付方式
+	Name          string     `json:"name"`           // 商品名称
+	Money         epay.Money `json:"money"`          // 订单金额（分）
+	RefundedMoney epay.Money `json:"refunded_money"` // 已退款金额（分）
+	Param         string     `json:"param"`          // 业务扩展参数
+	State         State      `json:"state"`          // 生命周期状态
+	CreatedAt     time.Time  `json:"created_at"`     // 创建时间
+	UpdatedAt     time.Time  `json:"updated_at"`     // 最后更新时间
+	PaidAt        time.Time  `json:"paid_at"`        // 支付时间
+	Version       int64      `json:"version"`        // 存储版本号（用于 CompareAndSwap）
+}
+
+// Event 订单状态变化事件
+type Event struct {
+	OutTradeNo string    // 商户订单号
+	From       State     // 变化前状态（创建订单时为空）
+	To         State     // 变化后状态
+	Source     string    // 事件来源
+	Order      Order     // 变化后的订单快照
+	At         time.Time // 发生时间
+}
+
+// Listener 事件监听函数
+type Listener func(event Event)
+
+// Tracker 订单跟踪器（并发安全）
+type Tracker struct {
+	store     OrderStore
+	mu        sync.RWMutex // 保护 listeners
+	listeners []Listener
+	now       func() time.Time
+}
+
+// Option 配置选项
+type Option func(*Tracker)
+
+// WithListener 注册事件监听函数
+func WithListener(listener Listener) Option {
+	return func(t *Tracker) {
+		t.listeners = append(t.listeners, listener)
+	}
+}
+
+// WithStore 设置订单存储（默认: 内存存储）
+func WithStore(store OrderStore) Option {
+	return func(t *Tracker) {
+		t.store = store
+	}
+}
+
+// WithClock 设置时间来源（用于测试）
+func WithClock(now func() time.Time) Option {
+	return func(t *Tracker) {
+		t.now = now
+	}
+}
+
+// New 创建订单跟踪器
+func New(opts ...Option) *Tracker {
+	t := &Tracker{
+		store: NewMemoryStore(),
+		now:   time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	return t
+}
+
+// Subscribe 注册事件监听函数
+func (t *Tracker) Subscribe(listener Listener) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.listeners = append(t.listeners, listener)
+}
+
+// Store 返回订单存储
+func (t *Tracker) Store() OrderStore {
+	return t.store
+}
+
+// Get 获取订单快照
+func (t *Tracker) Get(ctx context.Context, outTradeNo string) (*Order, error) {
+	return t.store.Get(ctx, outTradeNo)
+}
+
+// TrackPayment 记录通过 CreatePayment 创建的订单
+func (t *Tracker) TrackPayment(ctx context.Context, req *epay.PaymentRequest, resp *epay.PaymentResponse) (*Order, error) {
+	order := &Order{
+		OutTradeNo: req.OutTradeNo,
+		Type:       req.Type,
+		Name:       req.Name,
+		Money:      epay.MoneyFromFloat(req.Money),
+		Param:      req.Param,
+	}
+	if resp != nil {
+		order.TradeNo = resp.TradeNo
+	}
+	return t.track(ctx, order)
+}
+
+// TrackFormPayment 记录通过 BuildFormPayment/BuildFormPaymentURL 创建的订单
+func (t *Tracker) TrackFormPayment(ctx context.Context, req *epay.FormPaymentRequest) (*Order, error) {
+	return t.track(ctx, &Order{
+		OutTradeNo: req.OutTradeNo,
+		Type:       req.Type,
+		Name:       req.Name,
+		Money:      epay.MoneyFromFloat(req.Money),
+		Param:      req.Param,
+	})
+}
+
+// ApplyNotify 根据支付回调推进订单状态
+// 非 TRADE_SUCCESS 通知及重复通知不改变状态；已过期/已关闭的订单收到支付成功通知会返回非法迁移错误
+func (t *Tracker) ApplyNotify(ctx context.Context, data *epay.NotifyData) (*Order, error) {
+	if data.TradeStatus != epay.TradeStatusSuccess {
+		return t.Get(ctx, data.OutTradeNo)
+	}
+	return t.update(ctx, data.OutTradeNo, SourceNotify, func(order *Order) (State, error) {
+		if order.TradeNo == "" {
+			order.TradeNo = data.TradeNo
+		}
+		if order.State.isPaid() {
+			// 已支付或已进入退款流程，重复通知
+			return order.State, nil
+		}
+		return StatePaid, nil
+	})
+}
+
+// ApplyQuery 根据订单查询结果推进订单状态
+// 网关状态为已支付时迁移到 paid；为已退款时迁移到 refunded（必要时先经过 paid）
+func (t *Tracker) ApplyQuery(ctx context.Context, detail *epay.OrderDetail) (*Order, error) {
+	switch detail.Status {
+	case epay.OrderStatusPaid:
+		return t.update(ctx, detail.OutTradeNo, SourceQuery, func(order *Order) (State, error) {
+			if order.TradeNo == "" {
+				order.TradeNo = detail.TradeNo
+			}
+			if order.State.isPaid() {
+				return order.State, nil
+			}
+			return StatePaid, nil
+		})
+	case epay.OrderStatusRefunded:
+		if _, err := t.update(ctx, detail.OutTradeNo, SourceQuery, func(order *Order) (State, error) {
+			if order.State == StateCreated {
+				return StatePaid, nil
+			}
+			return order.State, nil
+		}); err != nil {
+			return nil, err
+		}
+		return t.update(ctx, detail.OutTradeNo, SourceQuery, func(order *Order) (State, error) {
+			if order.State == StateRefunded {
+				return order.State, nil
+			}
+			order.RefundedMoney = order.Money
+			return StateRefunded, nil
+		})
+	default:
+		return t.Get(ctx, detail.OutTradeNo)
+	}
+}
+
+// ApplyRefund 记录一笔成功的退款
+// 累计退款金额达到订单金额时迁移到 refunded，否则迁移到 partially_refunded
+func (t *Tracker) ApplyRefund(ctx context.Context, outTradeNo string, amount epay.Money) (*Order, error) {
+	return t.update(ctx, outTradeNo, SourceRefund, func(order *Order) (State, error) {
+		if amount <= 0 || order.RefundedMoney+amount > order.Money {
+			return "", ErrRefundExceeded
+		}
+		order.RefundedMoney += amount
+		if order.RefundedMoney == order.Money {
+			return StateRefunded, nil
+		}
+		return StatePartiallyRefunded, nil
+	})
+}
+
+// Expire 将未支付订单标记为已过期
+func (t *Tracker) Expire(ctx context.Context, outTradeNo string) (*Order, error) {
+	return t.update(ctx, outTradeNo, SourceExpire, func(order *Order) (State, error) {
+		return StateExpired, nil
+	})
+}
+
+// ExpireBefore 将创建时间早于 deadline 的未支付订单标记为已过期，返回过期的订单号
+func (t *Tracker) ExpireBefore(ctx context.Context, deadline time.Time) ([]string, error) {
+	candidates, err := t.store.List(ctx, ListFilter{
+		States:        []State{StateCreated},
+		CreatedBefore: deadline,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var expired []string
+	for _, order := range candidates {
+		if _, err := t.Expire(ctx, order.OutTradeNo); err == nil {
+			expired = append(expired, order.OutTradeNo)
+		}
+	}
+	return expired, nil
+}
+
+// Close 将未支付订单标记为已关闭（通常在 client.CloseOrder 之后调用）
+func (t *Tracker) Close(ctx context.Context, outTradeNo string) (*Order, error) {
+	return t.update(ctx, outTradeNo, SourceClose, func(order *Order) (State, error) {
+		return StateClosed, nil
+	})
+}
+
+// track 记录新订单
+func (t *Tracker) track(ctx context.Context, order *Order) (*Order, error) {
+	if order.OutTradeNo == "" {
+		return nil, epay.ErrMissingOutTradeNo
+	}
+
+	now := t.now()
+	order.State = StateCreated
+	order.CreatedAt = now
+	order.UpdatedAt = now
+	if err := t.store.Put(ctx, order); err != nil {
+		return nil, err
+	}
+
+	t.emit(Event{
+		OutTradeNo: order.OutTradeNo,
+		To:         StateCreated,
+		Source:     SourceCreate,
+		Order:      *order,
+		At:         now,
+	})
+	return order, nil
+}
+
+// update 计算并以 CompareAndSwap 应用状态迁移，状态变化时发出事件
+// mutate 返回目标状态；返回当前状态表示不迁移
+func (t *Tracker) update(ctx context.Context, outTradeNo, source string, mutate func(order *Order) (State, error)) (*Order, error) {
+	for {
+		order, err := t.store.Get(ctx, outTradeNo)
+		if err != nil {
+			return nil, err
+		}
+
+		from := order.State
+		before := *order
+		to, err := mutate(order)
+		if err != nil {
+			return nil, err
+		}
+		if to != from && !CanTransition(from, to) {
+			return nil, &TransitionError{OutTradeNo: outTradeNo, From: from, To: to}
+		}
+
+		// 部分退款 → 部分退款只在新增退款时发生
+		changed := to != from || source == SourceRefund
+		now := t.now()
+		if changed {
+			order.State = to
+			order.UpdatedAt = now
+			if to == StatePaid {
+				order.PaidAt = now
+			}
+		}
+
+		// 状态与字段都未变化（如重复回调）时不写入，避免文件存储每次追加日志
+		if !changed && *order == before {
+			return order, nil
+		}
+
+		// 状态不变时仍保存补充字段（如 trade_no）
+		err = t.store.CompareAndSwap(ctx, order)
+		if errors.Is(err, ErrVersionConflict) {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if changed {
+			t.emit(Event{
+				OutTradeNo: outTradeNo,
+				From:       from,
+				To:         to,
+				Source:     source,
+				Order:      *order,
+				At:         now,
+			})
+		}
+		return order, nil
+	}
+}
+
+// emit 依次调用事件监听函数
+func (t *Tracker) emit(event Event) {
+	t.mu.RLock()
+	listeners := t.listeners
+	t.mu.RUnlock()
+
+	for _, listener := range listeners {
+		listener(event)
+	}
+}
@@ -0,0 +1,124 @@
+package tracker
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	epay "github.com/liuscraft/epay-sdk-go"
+)
+
+func TestTracker_Lifecycle(t *testing.T) {
+	var events []Event
+	ctx := context.Background()
+	tr := New(WithListener(func(e Event) { events = append(events, e) }))
+
+	_, err := tr.TrackPayment(ctx, &epay.PaymentRequest{
+		Type:       epay.PayTypeAlipay,
+		OutTradeNo: "ORDER001",
+		Name:       "VIP",
+		Money:      10,
+	}, &epay.PaymentResponse{TradeNo: "T001"})
+	if err != nil {
+		t.Fatalf("TrackPayment() error = %v", err)
+	}
+
+	notify := &epay.NotifyData{OutTradeNo: "ORDER001", TradeNo: "T001", TradeStatus: epay.TradeStatusSuccess}
+	if order, err := tr.ApplyNotify(ctx, notify); err != nil || order.State != StatePaid {
+		t.Fatalf("ApplyNotify() = %v, %v", order, err)
+	}
+	// 重复通知不产生事件
+	if _, err := tr.ApplyNotify(ctx, notify); err != nil {
+		t.Fatalf("duplicate ApplyNotify() error = %v", err)
+	}
+
+	if order, err := tr.ApplyRefund(ctx, "ORDER001", 300); err != nil || order.State != StatePartiallyRefunded {
+		t.Fatalf("ApplyRefund(300) = %v, %v", order, err)
+	}
+	if _, err := tr.ApplyRefund(ctx, "ORDER001", 800); !errors.Is(err, ErrRefundExceeded) {
+		t.Fatalf("ApplyRefund(800) error = %v, want ErrRefundExceeded", err)
+	}
+	if order, err := tr.ApplyRefund(ctx, "ORDER001", 700); err != nil || order.State != StateRefunded {
+		t.Fatalf("ApplyRefund(700) = %v, %v", order, err)
+	}
+
+	want := []State{StateCreated, StatePaid, StatePartiallyRefunded, StateRefunded}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events, want %d", len(events), len(want))
+	}
+	for i, e := range events {
+		if e.To != want[i] {
+			t.Errorf("events[%d].To = %s, want %s", i, e.To, want[i])
+		}
+	}
+}
+
+func TestTracker_IllegalTransition(t *testing.T) {
+	ctx := context.Background()
+	tr := New()
+	tr.TrackFormPayment(ctx, &epay.FormPaymentRequest{OutTradeNo: "ORDER001", Money: 1})
+
+	if order, err := tr.Close(ctx, "ORDER001"); err != nil || order.State != StateClosed {
+		t.Fatalf("Close() = %v, %v", order, err)
+	}
+
+	// 关闭后才到达的支付通知
+	_, err := tr.ApplyNotify(ctx, &epay.NotifyData{OutTradeNo: "ORDER001", TradeStatus: epay.TradeStatusSuccess})
+	var transitionErr *TransitionError
+	if !errors.As(err, &transitionErr) || !errors.Is(err, ErrIllegalTransition) {
+		t.Fatalf("ApplyNotify() error = %v, want TransitionError", err)
+	}
+	if transitionErr.From != StateClosed || transitionErr.To != StatePaid {
+		t.Errorf("TransitionError = %+v", transitionErr)
+	}
+
+	if _, err := tr.Expire(ctx, "MISSING"); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("Expire() error = %v, want ErrOrderNotFound", err)
+	}
+}
+
+func TestTracker_ApplyQueryRefunded(t *testing.T) {
+	ctx := context.Background()
+	tr := New()
+	tr.TrackFormPayment(ctx, &epay.FormPaymentRequest{OutTradeNo: "ORDER001", Money: 5})
+
+	order, err := tr.ApplyQuery(ctx, &epay.OrderDetail{OutTradeNo: "ORDER001", Status: epay.OrderStatusRefunded})
+	if err != nil || order.State != StateRefunded || order.RefundedMoney != 500 {
+		t.Errorf("ApplyQuery() = %+v, %v", order, err)
+	}
+}
+
+// casCounter 统计 CompareAndSwap 调用次数的订单存储
+type casCounter struct {
+	OrderStore
+	n int
+}
+
+func (s *casCounter) CompareAndSwap(ctx context.Context, order *Order) error {
+	s.n++
+	return s.OrderStore.CompareAndSwap(ctx, order)
+}
+
+func TestTracker_DuplicateNotifyNoWrite(t *testing.T) {
+	ctx := context.Background()
+	store := &casCounter{OrderStore: NewMemoryStore()}
+	tr := New(WithStore(store))
+
+	if _, err := tr.TrackFormPayment(ctx, &epay.FormPaymentRequest{OutTradeNo: "ORDER001", Money: 10}); err != nil {
+		t.Fatalf("TrackFormPayment() error = %v", err)
+	}
+	notify := &epay.NotifyData{OutTradeNo: "ORDER001", TradeNo: "T001", TradeStatus: epay.TradeStatusSuccess}
+	for i := 0; i < 3; i++ {
+		if order, err := tr.ApplyNotify(ctx, notify); err != nil || order.State != StatePaid {
+			t.Fatalf("ApplyNotify() = %v, %v", order, err)
+		}
+	}
+
+	// 重复通知状态与字段均未变化，不再写入存储
+	if store.n != 1 {
+		t.Errorf("CompareAndSwap calls = %d, want 1", store.n)
+	}
+	if order, _ := tr.Get(ctx, "ORDER001"); order.Version != 2 {
+		t.Errorf("Version = %d, want 2", order.Version)
+	}
+}
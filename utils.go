@@ -1,7 +1,6 @@
 package epay
 
 import (
-	"net/http"
 	"net/url"
 	"sort"
 	"strings"
@@ -60,32 +59,6 @@ func BuildURLQuery(params map[string]string) string {
 	return values.Encode()
 }
 
-// ParseNotifyParams 从 HTTP 请求中解析回调参数
-// 支持 GET 和 POST 请求
-func ParseNotifyParams(r *http.Request) map[string]string {
-	params := make(map[string]string)
-
-	// 先尝试解析 URL 查询参数
-	for k, v := range r.URL.Query() {
-		if len(v) > 0 {
-			params[k] = v[0]
-		}
-	}
-
-	// 如果是 POST 请求，解析表单数据
-	if r.Method == http.MethodPost {
-		if err := r.ParseForm(); err == nil {
-			for k, v := range r.PostForm {
-				if len(v) > 0 {
-					params[k] = v[0]
-				}
-			}
-		}
-	}
-
-	return params
-}
-
 // MapToURLValues 将 map 转换为 url.Values
 func MapToURLValues(params map[string]string) url.Values {
 	values := url.Values{}
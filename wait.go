@@ -0,0 +1,161 @@
+package epay
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// WaitForPayment 默认轮询参数
+const (
+	DefaultWaitInitialInterval = time.Second
+	DefaultWaitMaxInterval     = 10 * time.Second
+	DefaultWaitMultiplier      = 1.5
+)
+
+// WaitOptions 等待支付的轮询参数
+type WaitOptions struct {
+	InitialInterval time.Duration // 首次轮询间隔（默认: 1s）
+	MaxInterval     time.Duration // 最大轮询间隔（默认: 10s）
+	Multiplier      float64       // 间隔增长倍数（默认: 1.5）
+	Timeout         time.Duration // 最长等待时间（默认: 0，仅受 context 约束）
+}
+
+// withDefaults 填充默认值
+func (o *WaitOptions) withDefaults() WaitOptions {
+	opts := WaitOptions{}
+	if o != nil {
+		opts = *o
+	}
+	if opts.InitialInterval <= 0 {
+		opts.InitialInterval = DefaultWaitInitialInterval
+	}
+	if opts.MaxInterval <= 0 {
+		opts.MaxInterval = DefaultWaitMaxInterval
+	}
+	if opts.MaxInterval < opts.InitialInterval {
+		opts.MaxInterval = opts.InitialInterval
+	}
+	if opts.Multiplier < 1 {
+		opts.Multiplier = DefaultWaitMultiplier
+	}
+	return opts
+}
+
+// WaitForPayment 等待订单支付完成
+// 按递增间隔轮询 QueryOrder，直到订单已支付、超时或 context 取消。
+// 等待期间若通过 SignalPaid（handler.Handlers.Notify 会自动调用）收到该订单的支付成功通知，立即返回。
+// 超时或取消时返回 context 错误；网络错误会继续轮询，其他查询错误直接返回。
+func (c *Client) WaitForPayment(ctx context.Context, outTradeNo string, opts *WaitOptions) (*OrderDetail, error) {
+	if outTradeNo == "" {
+		return nil, ErrMissingOutTradeNo
+	}
+
+	o := opts.withDefaults()
+	if o.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.Timeout)
+		defer cancel()
+	}
+
+	// 注册通知监听
+	notified, unregister := c.waiters.register(outTradeNo)
+	defer unregister()
+
+	interval := o.InitialInterval
+	for {
+		order, err := c.QueryOrderContext(ctx, &OrderQueryRequest{OutTradeNo: outTradeNo})
+		if err == nil && IsOrderPaid(order) {
+			return order, nil
+		}
+		if err != nil && !IsRetryable(err) {
+			return nil, err
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case data := <-notified:
+			timer.Stop()
+			return orderFromNotify(data), nil
+		case <-timer.C:
+		}
+
+		interval = time.Duration(float64(interval) * o.Multiplier)
+		if interval > o.MaxInterval {
+			interval = o.MaxInterval
+		}
+	}
+}
+
+// SignalPaid 通知正在 WaitForPayment 的调用方订单已支付
+// 只处理 TRADE_SUCCESS 通知，handler.Handlers.Notify 在回调成功后会自动调用
+func (c *Client) SignalPaid(data *NotifyData) {
+	if data == nil || data.TradeStatus != TradeStatusSuccess || data.OutTradeNo == "" {
+		return
+	}
+	c.waiters.signal(data)
+}
+
+// orderFromNotify 根据支付成功通知构建订单详情
+func orderFromNotify(data *NotifyData) *OrderDetail {
+	return &OrderDetail{
+		Code:       1,
+		TradeNo:    data.TradeNo,
+		OutTradeNo: data.OutTradeNo,
+		Type:       data.Type,
+		PID:        data.PID,
+		Name:       data.Name,
+		Money:      data.Money,
+		Status:     OrderStatusPaid,
+		Param:      data.Param,
+	}
+}
+
+// paymentWaiters 等待支付通知的监听者集合
+type paymentWaiters struct {
+	mu      sync.Mutex
+	waiters map[string]map[chan *NotifyData]struct{}
+}
+
+// newPaymentWaiters 创建监听者集合
+func newPaymentWaiters() *paymentWaiters {
+	return &paymentWaiters{
+		waiters: make(map[string]map[chan *NotifyData]struct{}),
+	}
+}
+
+// register 注册订单的通知监听，返回通知通道和注销函数
+func (p *paymentWaiters) register(outTradeNo string) (<-chan *NotifyData, func()) {
+	ch := make(chan *NotifyData, 1)
+
+	p.mu.Lock()
+	if p.waiters[outTradeNo] == nil {
+		p.waiters[outTradeNo] = make(map[chan *NotifyData]struct{})
+	}
+	p.waiters[outTradeNo][ch] = struct{}{}
+	p.mu.Unlock()
+
+	return ch, func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		delete(p.waiters[outTradeNo], ch)
+		if len(p.waiters[outTradeNo]) == 0 {
+			delete(p.waiters, outTradeNo)
+		}
+	}
+}
+
+// signal 唤醒订单的所有监听者
+func (p *paymentWaiters) signal(data *NotifyData) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for ch := range p.waiters[data.OutTradeNo] {
+		select {
+		case ch <- data:
+		default:
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package epay
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_WaitForPayment(t *testing.T) {
+	var calls int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.Write([]byte(`{"code":1,"out_trade_no":"ORDER001","status":0}`))
+			return
+		}
+		w.Write([]byte(`{"code":1,"out_trade_no":"ORDER001","status":1}`))
+	})
+
+	order, err := client.WaitForPayment(context.Background(), "ORDER001", &WaitOptions{
+		InitialInterval: time.Millisecond,
+		MaxInterval:     5 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("WaitForPayment() error = %v", err)
+	}
+	if !IsOrderPaid(order) || calls != 3 {
+		t.Errorf("WaitForPayment() = %+v after %d calls", order, calls)
+	}
+}
+
+func TestClient_WaitForPayment_Signal(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"code":1,"out_trade_no":"ORDER001","status":0}`))
+	})
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		client.SignalPaid(&NotifyData{OutTradeNo: "ORDER002", TradeStatus: TradeStatusSuccess})
+		client.SignalPaid(&NotifyData{OutTradeNo: "ORDER001", TradeNo: "T001", TradeStatus: TradeStatusSuccess})
+	}()
+
+	order, err := client.WaitForPayment(context.Background(), "ORDER001", &WaitOptions{
+		InitialInterval: time.Hour,
+		Timeout:         5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("WaitForPayment() error = %v", err)
+	}
+	if order.TradeNo != "T001" || !IsOrderPaid(order) {
+		t.Errorf("WaitForPayment() = %+v, want paid T001", order)
+	}
+}
+
+func TestClient_WaitForPayment_Timeout(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"code":1,"out_trade_no":"ORDER001","status":0}`))
+	})
+
+	_, err := client.WaitForPayment(context.Background(), "ORDER001", &WaitOptions{
+		InitialInterval: time.Millisecond,
+		Timeout:         20 * time.Millisecond,
+	})
+	if err != context.DeadlineExceeded {
+		t.Errorf("WaitForPayment() error = %v, want context.DeadlineExceeded", err)
+	}
+}